@@ -0,0 +1,161 @@
+package main
+
+import "time"
+
+const (
+    EventRoundSettled        = "round_settled"
+    EventTapOut              = "tap_out"
+    EventDrinksGiven         = "drinks_given"
+    EventGameFinished        = "game_finished"
+    EventAchievementUnlocked = "achievement_unlocked"
+    EventAchievementRevoked  = "achievement_revoked"
+)
+
+// GameEvent is an internal notification raised by the game functions as they
+// mutate a session. Achievement rules are evaluated against these events.
+type GameEvent struct {
+    Kind     string
+    PlayerID string
+    Round    int
+    Amount   int
+    Correct  bool
+}
+
+type Achievement struct {
+    ID          string `json:"id"`
+    Name        string `json:"name"`
+    Description string `json:"description"`
+}
+
+type AchievementUnlock struct {
+    Achievement
+    PlayerID   string    `json:"playerId"`
+    PlayerName string    `json:"playerName"`
+    Round      int       `json:"round"`
+    UnlockedAt time.Time `json:"unlockedAt"`
+}
+
+type achievementRule struct {
+    Achievement
+    match func(s *Session, p *Player, ev GameEvent) bool
+}
+
+var achievementRules = []achievementRule{
+    {
+        Achievement: Achievement{"flawless", "Flawless", "Survived all four rounds without a single miss"},
+        match: func(s *Session, p *Player, ev GameEvent) bool {
+            return ev.Kind == EventRoundSettled && ev.Round == 3 && perfectRun(s, p.ID)
+        },
+    },
+    {
+        Achievement: Achievement{"suit_streak", "Card Counter", "Guessed the suit three games in a row"},
+        match: func(s *Session, p *Player, ev GameEvent) bool {
+            return ev.Kind == EventGameFinished && p.SuitStreak >= 3
+        },
+    },
+    {
+        Achievement: Achievement{"chicken_16", "Not Today", "Tapped out with 16 sips on the line"},
+        match: func(s *Session, p *Player, ev GameEvent) bool {
+            return ev.Kind == EventTapOut && stakeForRound(ev.Round) == 16
+        },
+    },
+    {
+        Achievement: Achievement{"bottoms_up", "Bottoms Up", "Missed the suit and drank 16 at once"},
+        match: func(s *Session, p *Player, ev GameEvent) bool {
+            return ev.Kind == EventRoundSettled && ev.Round == 3 && !ev.Correct
+        },
+    },
+    {
+        Achievement: Achievement{"generous", "Generous Host", "Handed out 10 or more sips in one go"},
+        match: func(s *Session, p *Player, ev GameEvent) bool {
+            return ev.Kind == EventDrinksGiven && ev.Amount >= 10
+        },
+    },
+}
+
+func findPlayer(s *Session, playerID string) *Player {
+    for i := range s.Players {
+        if s.Players[i].ID == playerID {
+            return &s.Players[i]
+        }
+    }
+    return nil
+}
+
+func hasBadge(p *Player, id string) bool {
+    for _, b := range p.Badges {
+        if b == id {
+            return true
+        }
+    }
+    return false
+}
+
+// recordGameEvent updates achievement progress for the event's player and
+// awards any badge whose rule now matches. Each unlock is emitted as an
+// achievement_unlocked lobby event for the host TV.
+func recordGameEvent(s *Session, ev GameEvent) {
+    p := findPlayer(s, ev.PlayerID)
+    if p == nil {
+        return
+    }
+
+    if ev.Kind == EventGameFinished {
+        if suitGuessedCorrectly(s, p.ID) {
+            p.SuitStreak++
+        } else {
+            p.SuitStreak = 0
+        }
+    }
+
+    for _, rule := range achievementRules {
+        if hasBadge(p, rule.ID) || !rule.match(s, p, ev) {
+            continue
+        }
+        p.Badges = append(p.Badges, rule.ID)
+        s.emit(EventAchievementUnlocked, AchievementUnlock{
+            Achievement: rule.Achievement,
+            PlayerID:    p.ID,
+            PlayerName:  p.Name,
+            Round:       ev.Round,
+            UnlockedAt:  s.now(),
+        })
+    }
+}
+
+// revokeLostBadges tells clients about badges an undo took away, so the
+// host TV can drop the ones it just celebrated.
+func revokeLostBadges(s *Session, before map[string][]string) {
+    for _, p := range s.Players {
+        for _, id := range before[p.ID] {
+            if hasBadge(&p, id) {
+                continue
+            }
+            s.emit(EventAchievementRevoked, map[string]any{
+                "id":       id,
+                "playerId": p.ID,
+            })
+        }
+    }
+}
+
+// recordGameFinished raises EventGameFinished for everyone who played.
+func recordGameFinished(s *Session) {
+    played := map[string]bool{}
+    for _, r := range s.Game.Results {
+        if played[r.PlayerID] {
+            continue
+        }
+        played[r.PlayerID] = true
+        recordGameEvent(s, GameEvent{Kind: EventGameFinished, PlayerID: r.PlayerID, Round: s.Game.Round})
+    }
+}
+
+func suitGuessedCorrectly(s *Session, playerID string) bool {
+    for _, r := range s.Game.Results {
+        if r.PlayerID == playerID && r.Round == 3 {
+            return r.Correct
+        }
+    }
+    return false
+}
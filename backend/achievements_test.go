@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestTapOutAtSixteenUnlocksAchievement(t *testing.T) {
+    s := newTestSession("a")
+    s.Game.Round = 3
+
+    if err := TapOut(s, "a"); err != nil {
+        t.Fatal(err)
+    }
+
+    events := s.drainEvents()
+    if len(events) != 1 || events[0].Type != EventAchievementUnlocked {
+        t.Fatalf("expected one achievement event, got %+v", events)
+    }
+    if p := findPlayer(s, "a"); !hasBadge(p, "chicken_16") {
+        t.Errorf("expected chicken_16 badge, got %v", p.Badges)
+    }
+}
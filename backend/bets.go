@@ -0,0 +1,127 @@
+package main
+
+import "errors"
+
+const (
+    DrinkReasonSideBet = "side_bet"
+
+    EventSideBetSettled = "side_bet_settled"
+
+    maxSideBet = 5
+)
+
+// SideBet is a wager on whether another player's guess this round is right.
+// The loser of the bet drinks Amount, charged from the winner.
+type SideBet struct {
+    BettorID  string `json:"bettorId"`
+    TargetID  string `json:"targetId"`
+    Round     int    `json:"round"`
+    OnCorrect bool   `json:"onCorrect"`
+    Amount    int    `json:"amount"`
+    Settled   bool   `json:"settled,omitempty"`
+    Won       bool   `json:"won,omitempty"`
+}
+
+func requireOpenRound(s *Session) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if !s.Game.Started {
+        return errGameNotStarted
+    }
+    if s.Game.Paused {
+        return errGamePaused
+    }
+    return nil
+}
+
+// LetItRide puts all of a player's banked give-outs on this round's guess:
+// doubled if it is right, lost if it is wrong. It must be declared before
+// guessing.
+func LetItRide(s *Session, playerID string) error {
+    if err := requireOpenRound(s); err != nil {
+        return err
+    }
+    if !contains(s.Game.ActivePlayers, playerID) {
+        return errPlayerNotActive
+    }
+    if guessAt(s, playerID, s.Game.Round) != "" {
+        return errRideBeforeGuess
+    }
+    pot := s.Game.GiveOutRemainingByPlayer[playerID]
+    if pot <= 0 {
+        return errNothingToRide
+    }
+    if s.Game.RidingByPlayer == nil {
+        s.Game.RidingByPlayer = map[string]int{}
+    }
+    s.Game.RidingByPlayer[playerID] += pot
+    s.Game.GiveOutRemainingByPlayer[playerID] = 0
+    return nil
+}
+
+// PlaceSideBet records a bet on a player who has not guessed this round yet.
+func PlaceSideBet(s *Session, bet SideBet) error {
+    if err := requireOpenRound(s); err != nil {
+        return err
+    }
+    if s.Game.DistributionActive {
+        return errBetBetweenRounds
+    }
+    if bet.BettorID == s.HostID || !hasPlayer(s, bet.BettorID) {
+        return errPlayerNotInSession
+    }
+    if bet.BettorID == bet.TargetID {
+        return errBetOnSelf
+    }
+    if !contains(s.Game.ActivePlayers, bet.TargetID) {
+        return errBetTargetInactive
+    }
+    if guessAt(s, bet.TargetID, s.Game.Round) != "" {
+        return errBetTargetGuessed
+    }
+    if bet.Amount < 1 || bet.Amount > maxSideBet {
+        return codedErrorf("bet_amount", "bet must be between 1 and %d", maxSideBet)
+    }
+    for _, b := range s.Game.SideBets {
+        if b.Round == s.Game.Round && b.BettorID == bet.BettorID && b.TargetID == bet.TargetID {
+            return errBetAlreadyPlaced
+        }
+    }
+    bet.Round = s.Game.Round
+    bet.Settled, bet.Won = false, false
+    s.Game.SideBets = append(s.Game.SideBets, bet)
+    return nil
+}
+
+// settleRiding pays out or forfeits a player's riding pot.
+func settleRiding(s *Session, playerID string, correct bool) int {
+    pot := s.Game.RidingByPlayer[playerID]
+    if pot == 0 {
+        return 0
+    }
+    delete(s.Game.RidingByPlayer, playerID)
+    if !correct {
+        return 0
+    }
+    s.Game.GiveOutRemainingByPlayer[playerID] += 2 * pot
+    return 2 * pot
+}
+
+// settleSideBets resolves every bet on the given round.
+func settleSideBets(s *Session, round int, correctByPlayer map[string]bool) {
+    for i := range s.Game.SideBets {
+        b := &s.Game.SideBets[i]
+        if b.Round != round || b.Settled {
+            continue
+        }
+        b.Settled = true
+        b.Won = b.OnCorrect == correctByPlayer[b.TargetID]
+        if b.Won {
+            chargeDrink(s, b.TargetID, b.BettorID, b.Amount, DrinkReasonSideBet)
+        } else {
+            chargeDrink(s, b.BettorID, b.TargetID, b.Amount, DrinkReasonSideBet)
+        }
+        s.emit(EventSideBetSettled, *b)
+    }
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestLetItRideAndSideBets(t *testing.T) {
+    s := newTestSession("a", "b", "c")
+
+    playRound(t, s, map[string]string{"a": "red", "b": "red", "c": "red"})
+
+    // a rides 2 banked give-outs on "higher" (right), b rides on "lower" (wrong).
+    if err := LetItRide(s, "a"); err != nil {
+        t.Fatal(err)
+    }
+    if err := LetItRide(s, "b"); err != nil {
+        t.Fatal(err)
+    }
+    if err := PlaceSideBet(s, SideBet{BettorID: "c", TargetID: "b", OnCorrect: false, Amount: 3}); err != nil {
+        t.Fatal(err)
+    }
+    playRound(t, s, map[string]string{"a": "higher", "b": "lower", "c": "higher"})
+
+    if got := s.Game.GiveOutRemainingByPlayer["a"]; got != 4+4 {
+        t.Errorf("expected a to bank the doubled pot plus the stake, got %d", got)
+    }
+    if got := s.Game.GiveOutRemainingByPlayer["b"]; got != 0 {
+        t.Errorf("expected b to lose the riding pot, got %d", got)
+    }
+    if got := drinksTaken(s, "b", s.GamesPlayed); got != 4+3 {
+        t.Errorf("expected b to drink the stake and the lost side bet, got %d", got)
+    }
+}
+
+func TestSideBetsCloseOnceTheTargetGuessed(t *testing.T) {
+    s := newTestSession("a", "b")
+    s.Settings.DistributeEachRound = true
+
+    if err := SubmitGuess(s, "b", "red"); err != nil {
+        t.Fatal(err)
+    }
+    if err := PlaceSideBet(s, SideBet{BettorID: "a", TargetID: "b", OnCorrect: true, Amount: 1}); err == nil || err.Error() != "player already guessed this round" {
+        t.Errorf("expected a bet on a player who already guessed to be rejected, got %v", err)
+    }
+    if err := SubmitGuess(s, "a", "red"); err != nil {
+        t.Fatal(err)
+    }
+    if err := AdvanceRound(s); err != nil {
+        t.Fatal(err)
+    }
+
+    if !s.Game.Started || !s.Game.DistributionActive {
+        t.Fatalf("expected a between-rounds window, got %+v", s.Game)
+    }
+    if err := PlaceSideBet(s, SideBet{BettorID: "a", TargetID: "b", OnCorrect: true, Amount: 1}); err == nil || err.Error() != "wait for the next round to bet" {
+        t.Errorf("expected bets to wait for the next round, got %v", err)
+    }
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+    BotRandom    = "random"
+    BotAlwaysRed = "always_red"
+    BotOptimal   = "optimal"
+
+    maxBotsPerLobby = 12
+)
+
+var botStrategies = []string{BotRandom, BotAlwaysRed, BotOptimal}
+
+func validBotStrategy(strategy string) bool {
+    for _, s := range botStrategies {
+        if s == strategy {
+            return true
+        }
+    }
+    return false
+}
+
+// AddBot seats a server-driven player. Bots never drink for real, so the
+// host decides when they are welcome.
+func AddBot(s *Session, requesterID string, bot Player) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if requesterID != s.HostID {
+        return errHostOnlyBots
+    }
+    if s.Status != "active" {
+        return errSessionClosing
+    }
+    if !bot.Bot || !validBotStrategy(bot.BotStrategy) {
+        return errors.New("unknown bot strategy")
+    }
+    bots := 0
+    for _, p := range s.Players {
+        if p.Bot {
+            bots++
+        }
+    }
+    if bots >= maxBotsPerLobby {
+        return errors.New("too many bots")
+    }
+    s.Players = append(s.Players, bot)
+    return nil
+}
+
+// botGuess picks a bot's guess for the current round. always_red takes the
+// first option of every round (red, higher, between, hearts).
+func botGuess(s *Session, bot Player) (string, error) {
+    round := s.Game.Round
+    options := roundOptions(s.Settings.Rounds, round)
+    if len(options) == 0 {
+        return "", errors.New("invalid round")
+    }
+
+    switch bot.BotStrategy {
+    case BotAlwaysRed:
+        return options[0], nil
+    case BotOptimal:
+        odds := guessOdds(s.Settings.Rounds, s.Game.Shared, round)
+        best := options[0]
+        for _, opt := range options[1:] {
+            if odds[opt] > odds[best] {
+                best = opt
+            }
+        }
+        return best, nil
+    default:
+        i, err := cryptoInt(len(options))
+        if err != nil {
+            return "", err
+        }
+        return options[i], nil
+    }
+}
+
+// botAllocations spreads a bot's give-outs one sip at a time over random
+// eligible targets, staying inside the lobby's targeting rules.
+func botAllocations(s *Session, botID string) (map[string]int, error) {
+    left := s.Game.GiveOutRemainingByPlayer[botID]
+    limit := maxShareFor(s, botID)
+
+    allocations := map[string]int{}
+    for ; left > 0; left-- {
+        var pool []string
+        for _, t := range targetsFor(s, botID) {
+            if limit >= 0 && givenTo(s, botID, t)+allocations[t] >= limit {
+                continue
+            }
+            pool = append(pool, t)
+        }
+        if len(pool) == 0 {
+            break // whatever is left falls to the finalize strategy
+        }
+        i, err := cryptoInt(len(pool))
+        if err != nil {
+            return nil, err
+        }
+        allocations[pool[i]]++
+    }
+    return allocations, nil
+}
+
+func botName(strategy, locale string) (string, error) {
+    animal, err := pickWord(wordlistFor(locale).Animals)
+    if err != nil {
+        return "", err
+    }
+    return "Bot " + capitalize(animal) + " (" + strings.ReplaceAll(strategy, "_", "-") + ")", nil
+}
+
+// botThinkTime is a short random pause so bots feel like players, always
+// well inside the remaining time.
+func botThinkTime(deadline *time.Time) time.Duration {
+    wait := time.Second
+    if ms, err := cryptoInt(3000); err == nil {
+        wait += time.Duration(ms) * time.Millisecond
+    }
+    if deadline != nil {
+        if half := time.Until(*deadline) / 2; half < wait {
+            wait = half
+        }
+    }
+    if wait < 0 {
+        wait = 0
+    }
+    return wait
+}
+
+const (
+    botPhaseGuess      = "guess"
+    botPhaseDistribute = "distribute"
+)
+
+// botTimers tracks the bot moves already waiting on a timer. scheduleTimers
+// runs after every mutation, so without it each update would arm another
+// timer for the same move.
+type botTimers struct {
+    mu    sync.Mutex
+    armed map[string]struct{}
+}
+
+var pendingBots = &botTimers{armed: map[string]struct{}{}}
+
+func botTimerKey(code, botID string, game, round int, phase string) string {
+    return normalizeCode(code) + ":" + botID + ":" + strconv.Itoa(game) + ":" + strconv.Itoa(round) + ":" + phase
+}
+
+// arm reports whether the move was not scheduled yet, marking it scheduled.
+func (b *botTimers) arm(key string) bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if _, ok := b.armed[key]; ok {
+        return false
+    }
+    b.armed[key] = struct{}{}
+    return true
+}
+
+func (b *botTimers) release(key string) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    delete(b.armed, key)
+}
+
+// scheduleBots lets every bot that still owes a move act after a short pause.
+// A move already waiting on a timer is not scheduled again.
+func scheduleBots(ctx context.Context, code string, session *Session, store *RedisStore, bus *redisBus, hub *lobbyHub) {
+    if session.Game.Paused {
+        return
+    }
+    game, round := session.GamesPlayed, session.Game.Round
+
+    for _, p := range session.Players {
+        if !p.Bot {
+            continue
+        }
+        botID := p.ID
+        guessKey := botTimerKey(code, botID, game, round, botPhaseGuess)
+        distributeKey := botTimerKey(code, botID, game, round, botPhaseDistribute)
+
+        if session.Game.Started && !session.Game.DistributionActive && contains(session.Game.ActivePlayers, botID) && guessAt(session, botID, round) == "" && pendingBots.arm(guessKey) {
+            time.AfterFunc(botThinkTime(session.Game.Deadline), func() {
+                pendingBots.release(guessKey)
+                current, ok := store.GetSession(code)
+                if !ok || !current.Game.Started || current.GamesPlayed != game || current.Game.Round != round {
+                    return
+                }
+                bot := findPlayer(current, botID)
+                if bot == nil {
+                    return
+                }
+                guess, err := botGuess(current, *bot)
+                if err != nil {
+                    return
+                }
+                next, err := store.SubmitGuess(code, botID, guess)
+                if err != nil {
+                    return
+                }
+                publishSession(ctx, next, bus, hub)
+                if allGuessed(next) {
+                    if advanced, err := store.AdvanceRound(code); err == nil {
+                        publishSession(ctx, advanced, bus, hub)
+                        scheduleTimers(ctx, code, advanced, store, bus, hub)
+                    }
+                }
+            })
+        }
+
+        if session.Game.DistributionActive && session.Game.GiveOutRemainingByPlayer[botID] > 0 && pendingBots.arm(distributeKey) {
+            time.AfterFunc(botThinkTime(session.Game.DistributionDeadline), func() {
+                pendingBots.release(distributeKey)
+                current, ok := store.GetSession(code)
+                if !ok || !current.Game.DistributionActive || current.GamesPlayed != game {
+                    return
+                }
+                allocations, err := botAllocations(current, botID)
+                if err != nil || len(allocations) == 0 {
+                    return
+                }
+                next, err := store.DistributeDrinks(code, botID, allocations)
+                if err != nil {
+                    return
+                }
+                publishSession(ctx, next, bus, hub)
+                if next.Game.Started && !next.Game.DistributionActive {
+                    scheduleTimers(ctx, code, next, store, bus, hub)
+                }
+            })
+        }
+    }
+}
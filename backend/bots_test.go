@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestOptimalBotFollowsTheOdds(t *testing.T) {
+    s := newTestSessionWith([4]Card{{3, Hearts}, {12, Clubs}, {11, Spades}, {3, Diamonds}}, "a")
+    bot := Player{ID: "bot", Name: "Bot", Bot: true, BotStrategy: BotOptimal}
+    if err := AddBot(s, "host", bot); err != nil {
+        t.Fatal(err)
+    }
+    s.Game.Round = 1
+
+    guess, err := botGuess(s, bot)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if guess != "higher" {
+        t.Errorf("expected higher after a 3, got %q", guess)
+    }
+    if err := AddBot(s, "a", bot); err == nil {
+        t.Error("expected only the host to add bots")
+    }
+}
+
+func TestBotMoveIsArmedOnce(t *testing.T) {
+    timers := &botTimers{armed: map[string]struct{}{}}
+    key := botTimerKey("test", "bot", 0, 1, botPhaseGuess)
+
+    if !timers.arm(key) {
+        t.Fatal("expected the first schedule to arm the move")
+    }
+    if timers.arm(key) {
+        t.Error("expected a repeated schedule to be skipped")
+    }
+    if !timers.arm(botTimerKey("test", "bot", 0, 2, botPhaseGuess)) {
+        t.Error("expected the next round to arm its own move")
+    }
+    timers.release(key)
+    if !timers.arm(key) {
+        t.Error("expected the move to be schedulable again once its timer fired")
+    }
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Every lobby code is registered for the lobby's lifetime plus a cool-down,
+// so a closed or expired lobby's code is not handed to a new game while old
+// tabs may still point at it. Until then the registry entry is the lobby's
+// tombstone: GetSession serves it as an ended, closing session.
+
+const CodeCooldown = 24 * time.Hour
+
+var errLobbyEnded = newCodedError("lobby_ended", "this lobby ended")
+
+func codeKey(code string) string { return "code:" + normalizeCode(code) }
+
+// codeTombstone is what the registry remembers about a lobby once its
+// session is gone.
+type codeTombstone struct {
+    Code      string     `json:"code"`
+    HostID    string     `json:"hostId"`
+    CreatedAt time.Time  `json:"createdAt"`
+    EndedAt   *time.Time `json:"endedAt,omitempty"`
+}
+
+func tombstoneFor(session *Session) codeTombstone {
+    return codeTombstone{Code: session.Code, HostID: session.HostID, CreatedAt: session.CreatedAt}
+}
+
+// reserveCode claims a code unless a live or cooling-down lobby holds it.
+func (s *RedisStore) reserveCode(session *Session) (bool, error) {
+    b, _ := json.Marshal(tombstoneFor(session))
+    return s.rdb.SetNX(s.ctx, codeKey(session.Code), b, s.ttl+CodeCooldown).Result()
+}
+
+// holdCode keeps the registry entry (and the PIN pointing at it) alive for
+// the cool-down after the session's own expiry. Play at a tournament table
+// also keeps the tournament alive; it gets the full TTL even when one table
+// closes, since the other tables may still be playing.
+func holdCode(s *RedisStore, pipe redis.Pipeliner, session *Session, ttl time.Duration) {
+    pipe.Expire(s.ctx, codeKey(session.Code), ttl+CodeCooldown)
+    if session.PIN != "" {
+        pipe.Expire(s.ctx, pinKey(session.PIN), ttl+CodeCooldown)
+    }
+    if session.TournamentID != "" {
+        pipe.Expire(s.ctx, tournamentKey(session.TournamentID), s.ttl)
+    }
+}
+
+// endCode records when a closing lobby ends.
+func (s *RedisStore) endCode(session *Session, grace time.Duration) error {
+    tomb := tombstoneFor(session)
+    endedAt := time.Now().UTC().Add(grace)
+    tomb.EndedAt = &endedAt
+    b, _ := json.Marshal(tomb)
+    return s.rdb.Set(s.ctx, codeKey(session.Code), b, grace+CodeCooldown).Err()
+}
+
+// endedSession rebuilds an ended lobby from its tombstone.
+func (s *RedisStore) endedSession(code string) (*Session, bool) {
+    raw, err := s.rdb.Get(s.ctx, codeKey(code)).Result()
+    if err != nil {
+        return nil, false
+    }
+    var tomb codeTombstone
+    if err := json.Unmarshal([]byte(raw), &tomb); err != nil {
+        return nil, false
+    }
+    return &Session{
+        HostID:         tomb.HostID,
+        Code:           tomb.Code,
+        CreatedAt:      tomb.CreatedAt,
+        Status:         "closing",
+        ShuttingDownAt: tomb.EndedAt,
+        Ended:          true,
+    }, true
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEndedLobbyMessages(t *testing.T) {
+    if errCode, msg := localize("sv", errLobbyEnded); errCode != "lobby_ended" || msg != "Den här lobbyn har avslutats" {
+        t.Errorf("unexpected translation %q %q", errCode, msg)
+    }
+    session := &Session{Code: "BRAVE-RED-FOX", HostID: "host"}
+    b, _ := json.Marshal(tombstoneFor(session))
+    var tomb codeTombstone
+    if err := json.Unmarshal(b, &tomb); err != nil || tomb.Code != session.Code || tomb.HostID != session.HostID {
+        t.Errorf("unexpected tombstone %+v %v", tomb, err)
+    }
+    if got := codeKey(strings.ToLower(session.Code)); got != "code:"+normalizeCode(session.Code) {
+        t.Errorf("expected normalized registry key, got %q", got)
+    }
+}
@@ -0,0 +1,151 @@
+package main
+
+import "sort"
+
+const (
+    FinalizeRandom   = "random"
+    FinalizeEven     = "even"
+    FinalizeLeast    = "least"
+    FinalizeBack     = "back"
+    FinalizeRoulette = "roulette"
+
+    // Distinct from the distribution_finalized stream event so lobby event
+    // consumers can tell the two apart.
+    EventLeftoversAssigned = "leftovers_assigned"
+    EventRouletteReveal    = "roulette_reveal"
+)
+
+var finalizeStrategies = []string{FinalizeRandom, FinalizeEven, FinalizeLeast, FinalizeBack, FinalizeRoulette}
+
+func validFinalizeStrategy(strategy string) bool {
+    if strategy == "" {
+        return true
+    }
+    for _, s := range finalizeStrategies {
+        if s == strategy {
+            return true
+        }
+    }
+    return false
+}
+
+func (ls LobbySettings) finalizeStrategy() string {
+    if ls.FinalizeStrategy == "" {
+        return FinalizeRandom
+    }
+    return ls.FinalizeStrategy
+}
+
+// planFinalizeDistribution decides, without touching the session, where
+// every leftover give-out goes according to the lobby's strategy. The plan is
+// stored in the distribution_finalized event so replays are exact.
+func planFinalizeDistribution(s *Session) ([]DrinkAssignment, error) {
+    if !s.Game.DistributionActive {
+        return nil, nil
+    }
+
+    // Sorted givers keep the deterministic strategies stable.
+    givers := make([]string, 0, len(s.Game.GiveOutRemainingByPlayer))
+    for giverID, left := range s.Game.GiveOutRemainingByPlayer {
+        if left > 0 {
+            givers = append(givers, giverID)
+        }
+    }
+    sort.Strings(givers)
+
+    // planned tracks sips assigned in this plan, on top of what players
+    // already drank this game; plannedFrom tracks them per giver for the
+    // targeting share limit.
+    planned := map[string]int{}
+    plannedFrom := map[string]int{}
+    strategy := s.Settings.finalizeStrategy()
+
+    var assignments []DrinkAssignment
+    add := func(giverID, targetID string, amount int) {
+        planned[targetID] += amount
+        if targetID != giverID {
+            plannedFrom[giverID+"|"+targetID] += amount
+        }
+        n := len(assignments)
+        if n > 0 && assignments[n-1].GiverID == giverID && assignments[n-1].TargetID == targetID {
+            assignments[n-1].Amount += amount
+            return
+        }
+        assignments = append(assignments, DrinkAssignment{GiverID: giverID, TargetID: targetID, Amount: amount})
+    }
+
+    for _, giverID := range givers {
+        left := s.Game.GiveOutRemainingByPlayer[giverID]
+        limit := maxShareFor(s, giverID)
+        all := targetsFor(s, giverID)
+
+        // room is how many more sips the target may take from this giver
+        // under the share limit, or -1 when unlimited.
+        room := func(targetID string) int {
+            if limit < 0 {
+                return -1
+            }
+            return limit - givenTo(s, giverID, targetID) - plannedFrom[giverID+"|"+targetID]
+        }
+        open := func() []string {
+            var out []string
+            for _, t := range all {
+                if room(t) != 0 {
+                    out = append(out, t)
+                }
+            }
+            return out
+        }
+
+        for left > 0 {
+            pool := open()
+            // If no eligible target has room, giver drinks it.
+            if len(pool) == 0 || strategy == FinalizeBack {
+                add(giverID, giverID, left)
+                break
+            }
+
+            switch strategy {
+            case FinalizeRoulette:
+                // One spin takes as much as the winner may still receive;
+                // anything over the limit goes to another spin.
+                j, err := cryptoInt(len(pool))
+                if err != nil {
+                    return nil, err
+                }
+                amount := left
+                if r := room(pool[j]); r > 0 && r < amount {
+                    amount = r
+                }
+                add(giverID, pool[j], amount)
+                left -= amount
+            case FinalizeEven, FinalizeLeast:
+                best := pool[0]
+                for _, t := range pool[1:] {
+                    if finalizeLoad(s, strategy, planned, t) < finalizeLoad(s, strategy, planned, best) {
+                        best = t
+                    }
+                }
+                add(giverID, best, 1)
+                left--
+            default:
+                j, err := cryptoInt(len(pool))
+                if err != nil {
+                    return nil, err
+                }
+                add(giverID, pool[j], 1)
+                left--
+            }
+        }
+    }
+    return assignments, nil
+}
+
+// finalizeLoad is what the even and least strategies minimise: sips from
+// this plan only, or everything the player drank this game.
+func finalizeLoad(s *Session, strategy string, planned map[string]int, playerID string) int {
+    if strategy == FinalizeLeast {
+        return drinksTaken(s, playerID, s.GamesPlayed) + planned[playerID]
+    }
+    return planned[playerID]
+}
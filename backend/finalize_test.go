@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestFinalizeEvenSpreadsLeftovers(t *testing.T) {
+    s := newTestSession("a", "b", "c")
+    s.Settings.FinalizeStrategy = FinalizeEven
+    s.Game.Started = false
+    s.Game.DistributionActive = true
+    s.Game.GiveOutRemainingByPlayer = map[string]int{"a": 6}
+
+    if err := FinalizeDistribution(s); err != nil {
+        t.Fatal(err)
+    }
+    if s.Game.DrinkNowByPlayer["b"] != 3 || s.Game.DrinkNowByPlayer["c"] != 3 {
+        t.Errorf("expected an even 3/3 split, got %v", s.Game.DrinkNowByPlayer)
+    }
+    if len(s.Game.FinalizeAssignments) == 0 {
+        t.Error("expected assignments to be recorded")
+    }
+}
+
+func TestFinalizeRouletteRespectsShareLimit(t *testing.T) {
+    s := newTestSession("a", "b", "c")
+    s.Settings.FinalizeStrategy = FinalizeRoulette
+    s.Settings.Targeting.MaxSharePercent = 50
+    s.Game.Started = false
+    s.Game.DistributionActive = true
+    s.Game.GiveOutRemainingByPlayer = map[string]int{"a": 6}
+
+    if err := FinalizeDistribution(s); err != nil {
+        t.Fatal(err)
+    }
+    if s.Game.DrinkNowByPlayer["b"] != 3 || s.Game.DrinkNowByPlayer["c"] != 3 {
+        t.Errorf("expected the 50%% limit to split the spin 3/3, got %v", s.Game.DrinkNowByPlayer)
+    }
+}
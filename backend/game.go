@@ -19,6 +19,7 @@ const (
 
 const RoundDuration = 15 * time.Second
 const DistributionDuration = 20 * time.Second
+const RoundDistributionDuration = 10 * time.Second
 
 var Suits = []Suit{Hearts, Diamonds, Clubs, Spades}
 
@@ -27,6 +28,21 @@ type Card struct {
     Suit Suit `json:"suit"`
 }
 
+// RoundResult records how a single player's guess was settled by AdvanceRound.
+// The list in GameState.Results is the source of truth for past outcomes, so
+// clients never need to re-derive correctness from the shared cards.
+type RoundResult struct {
+    Round     int    `json:"round"`
+    PlayerID  string `json:"playerId"`
+    Guess     string `json:"guess"`
+    Correct   bool   `json:"correct"`
+    Stake     int    `json:"stake"`
+    Drank     int    `json:"drank"`
+    Given     int    `json:"given"` // give-outs earned this round
+    Rode      int    `json:"rode,omitempty"` // banked give-outs let ride
+    Challenge string `json:"challenge,omitempty"`
+}
+
 type GameState struct {
     Started                  bool                `json:"started"`
     Round                    int                 `json:"round"` // 0..3 during guesses, 4+ after
@@ -40,6 +56,31 @@ type GameState struct {
     DrinkNowByPlayer         map[string]int      `json:"drinkNowByPlayer"`
     GiveOutRemainingByPlayer map[string]int      `json:"giveOutRemainingByPlayer"`
     PendingTapOutByPlayer    map[string]bool     `json:"pendingTapOutByPlayer"`
+    // StatusByPlayer records players who left the game: tapped_out or
+    // eliminated. Anyone missing is still active.
+    StatusByPlayer           map[string]string   `json:"statusByPlayer,omitempty"`
+
+    Results                  []RoundResult       `json:"results"`
+
+    // While paused the running deadline is cleared and its remaining time is
+    // kept here, so stale timers drop out and resume can re-arm them.
+    Paused                   bool                `json:"paused"`
+    RemainingMs              int64               `json:"remainingMs,omitempty"`
+
+    // FinalizeAssignments lists where leftover give-outs went when the
+    // distribution window closed, so clients can animate it.
+    FinalizeAssignments      []DrinkAssignment   `json:"finalizeAssignments,omitempty"`
+
+    ChallengeDeck            []string            `json:"challengeDeck,omitempty"`
+    ChallengesDrawn          int                 `json:"challengesDrawn,omitempty"`
+
+    // RidingByPlayer holds banked give-outs let ride on this round's guess.
+    RidingByPlayer           map[string]int      `json:"ridingByPlayer,omitempty"`
+    SideBets                 []SideBet           `json:"sideBets,omitempty"`
+
+    // Odds holds the chance of each option this round when training wheels
+    // are on.
+    Odds                     map[string]float64  `json:"odds,omitempty"`
 }
 
 func StartGame(s *Session) error {
@@ -47,15 +88,36 @@ func StartGame(s *Session) error {
         return errors.New("session required")
     }
     if s.Game.Started {
-        return errors.New("game already started")
+        return errGameAlreadyStarted
     }
 
     cards, err := drawUniqueCards(4)
     if err != nil {
         return err
     }
+    deck, err := shuffledDeck(s.Settings.Penalty.Deck)
+    if err != nil {
+        return err
+    }
+    return startGameWithCards(s, cards, deck)
+}
 
-    deadline := time.Now().UTC().Add(RoundDuration)
+// startGameWithCards deals the given cards and challenge deck; split out of
+// StartGame so a replayed game_started event produces the exact same game.
+func startGameWithCards(s *Session, cards []Card, challenges []string) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if s.Game.Started {
+        return errGameAlreadyStarted
+    }
+    if len(cards) != 4 {
+        return errors.New("four cards required")
+    }
+    // The last game's rounds can no longer be undone.
+    s.Undo = nil
+
+    deadline := s.now().Add(RoundDuration)
 
     var active []string
     for _, p := range s.Players {
@@ -76,7 +138,19 @@ func StartGame(s *Session) error {
         DrinkNowByPlayer:         map[string]int{},
         GiveOutRemainingByPlayer: map[string]int{},
         PendingTapOutByPlayer:    map[string]bool{},
+        Results:                  []RoundResult{},
+        ChallengeDeck:            challenges,
+    }
+    s.GamesPlayed++
+    refreshOdds(s)
+
+    dealt := make([]Player, 0, len(active))
+    for _, p := range s.Players {
+        if p.ID != s.HostID {
+            dealt = append(dealt, Player{ID: p.ID, Name: p.Name})
+        }
     }
+    s.logHistory(HistoryEntry{Type: HistoryDeal, Cards: cards, Players: dealt})
     return nil
 }
 
@@ -100,10 +174,13 @@ func TapOut(s *Session, playerID string) error {
         return errors.New("session required")
     }
     if !s.Game.Started {
-        return errors.New("game not started")
+        return errGameNotStarted
+    }
+    if s.Game.Paused {
+        return errGamePaused
     }
     if !hasPlayer(s, playerID) {
-        return errors.New("player not in session")
+        return errPlayerNotInSession
     }
 
     // must be active
@@ -115,7 +192,7 @@ func TapOut(s *Session, playerID string) error {
         }
     }
     if !isActive {
-        return errors.New("player already tapped out")
+        return errAlreadyTappedOut
     }
 
     // allow tap-out request anytime during current round
@@ -123,10 +200,67 @@ func TapOut(s *Session, playerID string) error {
         s.Game.PendingTapOutByPlayer = map[string]bool{}
     }
     if s.Game.PendingTapOutByPlayer[playerID] {
-        return errors.New("tap out already requested")
+        return errTapOutAlreadyRequested
     }
 
     s.Game.PendingTapOutByPlayer[playerID] = true
+    s.logHistory(HistoryEntry{Type: HistoryTapOut, Round: s.Game.Round, PlayerID: playerID})
+    recordGameEvent(s, GameEvent{Kind: EventTapOut, PlayerID: playerID, Round: s.Game.Round})
+    return nil
+}
+
+func PauseGame(s *Session, requesterID string) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if requesterID != s.HostID {
+        return errHostOnlyPause
+    }
+    if s.Game.Paused {
+        return errGameAlreadyPaused
+    }
+
+    var deadline *time.Time
+    switch {
+    case s.Game.DistributionActive:
+        deadline = s.Game.DistributionDeadline
+    case s.Game.Started:
+        deadline = s.Game.Deadline
+    }
+    if deadline == nil {
+        return errNothingToPause
+    }
+
+    remaining := deadline.Sub(s.now())
+    if remaining < 0 {
+        remaining = 0
+    }
+    s.Game.Paused = true
+    s.Game.RemainingMs = remaining.Milliseconds()
+    s.Game.Deadline = nil
+    s.Game.DistributionDeadline = nil
+    return nil
+}
+
+func ResumeGame(s *Session, requesterID string) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if requesterID != s.HostID {
+        return errHostOnlyResume
+    }
+    if !s.Game.Paused {
+        return errGameNotPaused
+    }
+
+    deadline := s.now().Add(time.Duration(s.Game.RemainingMs) * time.Millisecond)
+    if s.Game.DistributionActive {
+        s.Game.DistributionDeadline = &deadline
+    } else if s.Game.Started {
+        s.Game.Deadline = &deadline
+    }
+    s.Game.Paused = false
+    s.Game.RemainingMs = 0
     return nil
 }
 
@@ -143,7 +277,7 @@ func distributionTargets(s *Session) []string {
     // Only non-spectators (active players) are valid targets.
     targets := make([]string, 0, len(s.Game.ActivePlayers))
     for _, pid := range s.Game.ActivePlayers {
-        if pid == s.HostID {
+        if pid == s.HostID || isProtected(s, pid) {
             continue
         }
         targets = append(targets, pid)
@@ -151,15 +285,44 @@ func distributionTargets(s *Session) []string {
     return targets
 }
 
+// targetsFor lists who a giver may hand drinks to. When no other active
+// player is eligible, everyone in the lobby is, so give-outs are never
+// wasted.
+func targetsFor(s *Session, giverID string) []string {
+    eligible := func(ids []string) []string {
+        var out []string
+        for _, pid := range ids {
+            if pid != giverID && pid != s.HostID && !isProtected(s, pid) && !sameTeam(s, giverID, pid) {
+                out = append(out, pid)
+            }
+        }
+        return out
+    }
+    if targets := eligible(distributionTargets(s)); len(targets) > 0 {
+        return targets
+    }
+    everyone := make([]string, 0, len(s.Players))
+    for _, p := range s.Players {
+        everyone = append(everyone, p.ID)
+    }
+    return eligible(everyone)
+}
+
 func AdvanceRound(s *Session) error {
     if s == nil {
         return errors.New("session required")
     }
     if !s.Game.Started {
-        return errors.New("game not started")
+        return errGameNotStarted
     }
     if s.Game.Round > 3 {
-        return errors.New("game already finished")
+        return errGameAlreadyFinished
+    }
+    if s.Game.Paused {
+        return errGamePaused
+    }
+    if s.Game.DistributionActive {
+        return errDistributionInProgress
     }
 
     round := s.Game.Round
@@ -189,30 +352,63 @@ func AdvanceRound(s *Session) error {
             continue
         }
 
-        guesses := s.Game.Guesses[p.ID]
+        // In team mode every member is settled on the team's guess.
+        guess := teamGuess(s, p.ID, round)
         correct := false
-        if len(guesses) > round {
-            correct = isCorrectGuess(s.Game.Shared, round, guesses[round])
+        if guess != "" {
+            correct = correctGuess(s.Settings.Rounds, s.Game.Shared, round, guess)
         }
 
+        result := RoundResult{
+            Round:    round,
+            PlayerID: p.ID,
+            Guess:    guess,
+            Correct:  correct,
+            Stake:    stake,
+            Rode:     s.Game.RidingByPlayer[p.ID],
+        }
+        won := settleRiding(s, p.ID, correct)
         if correct {
             s.Game.GiveOutRemainingByPlayer[p.ID] += stake
             correctByPlayer[p.ID] = true
+            p.RoundsSurvived++
+            // Losing a round eliminates, so surviving the last one is a
+            // perfect run.
+            if round == 3 {
+                p.PerfectRuns++
+                p.LastPerfectGame = s.GamesPlayed
+            }
+            result.Given = stake + won
         } else {
-            s.Game.DrinkNowByPlayer[p.ID] += stake
-            p.Score += stake
-            p.LifetimeDrank += stake
+            result.Drank = chargeDrink(s, p.ID, "", stake, DrinkReasonLostRound)
+            if card := drawChallenge(s); card != "" {
+                result.Challenge = card
+                s.emit(EventChallengeDrawn, map[string]any{
+                    "playerId":   p.ID,
+                    "playerName": p.Name,
+                    "round":      round,
+                    "amount":     stake,
+                    "challenge":  card,
+                })
+            }
             correctByPlayer[p.ID] = false
         }
+        s.Game.Results = append(s.Game.Results, result)
+        s.logHistory(HistoryEntry{Type: HistoryRoundSettled, Round: round, PlayerID: p.ID, Guess: guess, Correct: correct, Amount: stake})
+        recordGameEvent(s, GameEvent{Kind: EventRoundSettled, PlayerID: p.ID, Round: round, Amount: stake, Correct: correct})
     }
 
+    settleSideBets(s, round, correctByPlayer)
+
     // keep only correct players who did not request tap-out
     nextActive := make([]string, 0, len(s.Game.ActivePlayers))
     for _, pid := range s.Game.ActivePlayers {
         if !correctByPlayer[pid] {
+            setPlayerStatus(s, pid, PlayerEliminated)
             continue
         }
         if s.Game.PendingTapOutByPlayer[pid] {
+            applyTapOut(s, pid)
             continue
         }
         nextActive = append(nextActive, pid)
@@ -224,26 +420,45 @@ func AdvanceRound(s *Session) error {
     if len(s.Game.ActivePlayers) == 0 {
         s.Game.Started = false
         s.Game.Deadline = nil
+        s.Game.Round++ // keeps UI in result
+        s.Game.Odds = nil
+
+        // Earned give-outs are never lost: hand them out before finishing.
+        if hasAnyGiveOutRemaining(s) {
+            deadline := s.now().Add(DistributionDuration)
+            s.Game.DistributionActive = true
+            s.Game.DistributionDeadline = &deadline
+            return nil
+        }
 
-        // Do NOT open distribution here
         s.Game.DistributionActive = false
         s.Game.DistributionDeadline = nil
-
-        s.Game.Round++ // keeps UI in result
+        s.logHistory(HistoryEntry{Type: HistoryGameFinished, Round: s.Game.Round})
+        recordGameFinished(s)
         return nil
     }
 
     s.Game.Round++
     if s.Game.Round > 3 {
-        deadline := time.Now().UTC().Add(DistributionDuration)
+        deadline := s.now().Add(DistributionDuration)
         s.Game.Started = false
         s.Game.Deadline = nil
         s.Game.DistributionActive = true
         s.Game.DistributionDeadline = &deadline
+        s.Game.Odds = nil
         return nil
     }
 
-    next := time.Now().UTC().Add(RoundDuration)
+    refreshOdds(s)
+    if s.Settings.DistributeEachRound && hasAnyGiveOutRemaining(s) {
+        // The next round starts when this window closes.
+        window := s.now().Add(RoundDistributionDuration)
+        s.Game.Deadline = nil
+        s.Game.DistributionActive = true
+        s.Game.DistributionDeadline = &window
+        return nil
+    }
+    next := s.now().Add(RoundDuration)
     s.Game.Deadline = &next
     return nil
 }
@@ -252,20 +467,29 @@ func DistributeDrinks(s *Session, fromPlayerID string, allocations map[string]in
     if s == nil {
         return errors.New("session required")
     }
-    if !s.Game.DistributionActive {
-        return errors.New("distribution not active")
-    }
     if fromPlayerID == "" {
-        return errors.New("player required")
+        return errPlayerRequired
+    }
+    if s.Game.Paused {
+        return errGamePaused
+    }
+    if !s.Game.DistributionActive && !canCashOut(s, fromPlayerID) {
+        return errDistributionNotActive
     }
 
     remaining := s.Game.GiveOutRemainingByPlayer[fromPlayerID]
     if remaining <= 0 {
-        return errors.New("no drinks left to give")
+        return errNoDrinksLeft
+    }
+
+    // Targeting rules run first so protected players get their own message
+    // rather than a generic invalid target.
+    if err := checkTargeting(s, fromPlayerID, allocations); err != nil {
+        return err
     }
 
     validTarget := map[string]bool{}
-    for _, pid := range distributionTargets(s) {
+    for _, pid := range targetsFor(s, fromPlayerID) {
         validTarget[pid] = true
     }
 
@@ -275,49 +499,84 @@ func DistributeDrinks(s *Session, fromPlayerID string, allocations map[string]in
             continue
         }
         if targetID == fromPlayerID {
-            return errors.New("cannot give drinks to yourself")
+            return errSelfTarget
+        }
+        if sameTeam(s, fromPlayerID, targetID) {
+            return errTeamTarget
         }
         if !validTarget[targetID] {
-            return errors.New("invalid target player")
+            return errInvalidTarget
         }
         used += amount
     }
     if used <= 0 {
-        return errors.New("no allocation provided")
+        return errNoAllocation
     }
     if used > remaining {
-        return errors.New("allocated more than available")
+        return errOverAllocated
     }
 
-    for targetID, amount := range allocations {
+    revengeOn := ""
+    if s.Settings.Targeting.RevengeBonus > 0 {
+        revengeOn = lastGiverTo(s, fromPlayerID)
+    }
+
+    // Sorted so caps and redirects resolve the same way on replay.
+    for _, targetID := range sortedKeys(allocations) {
+        amount := allocations[targetID]
         if amount <= 0 {
             continue
         }
-        s.Game.DrinkNowByPlayer[targetID] += amount
-        for i := range s.Players {
-            if s.Players[i].ID == targetID {
-                s.Players[i].Score += amount
-                s.Players[i].LifetimeDrank += amount
-                break
-            }
+        chargeDrink(s, targetID, fromPlayerID, amount, DrinkReasonGiven)
+        if targetID == revengeOn {
+            bonus := s.Settings.Targeting.RevengeBonus
+            chargeDrink(s, targetID, fromPlayerID, bonus, DrinkReasonRevenge)
+            s.emit(EventRevenge, map[string]any{
+                "giverId":  fromPlayerID,
+                "targetId": targetID,
+                "bonus":    bonus,
+            })
         }
     }
 
     s.Game.GiveOutRemainingByPlayer[fromPlayerID] -= used
-    for i := range s.Players {
-        if s.Players[i].ID == fromPlayerID {
-            s.Players[i].GivenOut += used
-            break
+    given := make(map[string]int, len(allocations))
+    for targetID, amount := range allocations {
+        if amount > 0 {
+            given[targetID] = amount
         }
     }
+    s.logHistory(HistoryEntry{Type: HistoryAllocation, Round: s.Game.Round, PlayerID: fromPlayerID, Allocations: given, Amount: used})
+    recordGameEvent(s, GameEvent{Kind: EventDrinksGiven, PlayerID: fromPlayerID, Round: s.Game.Round, Amount: used})
 
-    if allDistributed(s) {
+    if s.Game.DistributionActive && allDistributed(s) {
         return FinalizeDistribution(s)
     }
     return nil
 }
 
+// DrinkAssignment is a batch of leftover sips handed out when the
+// distribution window closes.
+type DrinkAssignment struct {
+    GiverID  string `json:"giverId"`
+    TargetID string `json:"targetId"`
+    Amount   int    `json:"amount,omitempty"` // 0 in old events means 1
+}
+
 func FinalizeDistribution(s *Session) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    assignments, err := planFinalizeDistribution(s)
+    if err != nil {
+        return err
+    }
+    return applyFinalizeDistribution(s, assignments)
+}
+
+// applyFinalizeDistribution charges the planned assignments and closes the
+// distribution window.
+func applyFinalizeDistribution(s *Session, assignments []DrinkAssignment) error {
     if s == nil {
         return errors.New("session required")
     }
@@ -325,46 +584,66 @@ func FinalizeDistribution(s *Session) error {
         return nil
     }
 
-    targets := distributionTargets(s)
-    for giverID, left := range s.Game.GiveOutRemainingByPlayer {
-        for left > 0 {
-            pool := make([]string, 0, len(targets))
-            for _, t := range targets {
-                if t != giverID {
-                    pool = append(pool, t)
-                }
-            }
-
-            // If no eligible target exists, giver drinks it.
-            targetID := giverID
-            if len(pool) > 0 {
-                j, err := cryptoInt(len(pool))
-                if err != nil {
-                    return err
-                }
-                targetID = pool[j]
-            }
-
-            s.Game.DrinkNowByPlayer[targetID]++
-            for i := range s.Players {
-                if s.Players[i].ID == targetID {
-                    s.Players[i].Score++
-                    s.Players[i].LifetimeDrank++
-                    break
-                }
-            }
+    for i := range assignments {
+        a := &assignments[i]
+        if a.Amount <= 0 {
+            a.Amount = 1
+        }
+        chargeDrink(s, a.TargetID, a.GiverID, a.Amount, DrinkReasonLeftover)
+        s.logHistory(HistoryEntry{Type: HistoryFinalizeAssign, Round: s.Game.Round, PlayerID: a.GiverID, TargetID: a.TargetID, Amount: a.Amount})
+        s.Game.GiveOutRemainingByPlayer[a.GiverID] -= a.Amount
+    }
+    s.Game.FinalizeAssignments = assignments
 
-            s.Game.GiveOutRemainingByPlayer[giverID]--
-            left--
+    strategy := s.Settings.finalizeStrategy()
+    if strategy == FinalizeRoulette {
+        for _, a := range assignments {
+            s.emit(EventRouletteReveal, a)
         }
     }
+    s.emit(EventLeftoversAssigned, map[string]any{
+        "strategy":    strategy,
+        "assignments": assignments,
+    })
 
     s.Game.DistributionActive = false
     s.Game.DistributionDeadline = nil
     s.Game.Deadline = nil
+    // A finalize forced through a pause ends it: there is no timer left to
+    // resume.
+    s.Game.Paused = false
+    s.Game.RemainingMs = 0
+    if s.Game.Started {
+        // A between-rounds window closed: play on.
+        next := s.now().Add(RoundDuration)
+        s.Game.Deadline = &next
+        return nil
+    }
+    s.logHistory(HistoryEntry{Type: HistoryGameFinished, Round: s.Game.Round})
+    recordGameFinished(s)
     return nil
 }
 
+// gameFinished reports whether the current game has been played to the end:
+// no rounds left to guess and no distribution window open.
+func gameFinished(s *Session) bool {
+    if s == nil {
+        return false
+    }
+    return !s.Game.Started && !s.Game.DistributionActive && s.Game.Round > 0
+}
+
+// perfectRun reports whether the player guessed all four rounds correctly.
+func perfectRun(s *Session, playerID string) bool {
+    survived := 0
+    for _, r := range s.Game.Results {
+        if r.PlayerID == playerID && r.Correct {
+            survived++
+        }
+    }
+    return survived == 4
+}
+
 func allDistributed(s *Session) bool {
     if s == nil {
         return false
@@ -383,29 +662,41 @@ func SubmitGuess(s *Session, playerID, guess string) error {
         return errors.New("session required")
     }
     if !s.Game.Started {
-        return errors.New("game not started")
+        return errGameNotStarted
     }
     if s.Game.Round < 0 || s.Game.Round > 3 {
         return errors.New("invalid round")
     }
+    if s.Game.Paused {
+        return errGamePaused
+    }
+    if s.Game.DistributionActive {
+        return errDistributionInProgress
+    }
     if !hasPlayer(s, playerID) {
-        return errors.New("player not in session")
+        return errPlayerNotInSession
+    }
+
+    // Bots have no one to acknowledge their drinks.
+    if s.Settings.RequireDrinkAck && !findPlayer(s, playerID).Bot && pendingDrinkTotal(s, playerID) > 0 {
+        return errPendingDrinks
     }
 
     guess = normalizeGuess(guess)
-    if !validGuessForRound(s.Game.Round, guess) {
-        return errors.New("invalid guess for round")
+    if !validGuess(s.Settings.Rounds, s.Game.Round, guess) {
+        return errInvalidGuess
     }
 
     arr := s.Game.Guesses[playerID]
     if len(arr) > s.Game.Round {
-        return errors.New("guess already submitted for this round")
+        return errGuessAlreadySubmitted
     }
     for len(arr) < s.Game.Round {
         arr = append(arr, "")
     }
     arr = append(arr, guess)
     s.Game.Guesses[playerID] = arr
+    s.logHistory(HistoryEntry{Type: HistoryGuess, Round: s.Game.Round, PlayerID: playerID, Guess: guess})
     return nil
 }
 
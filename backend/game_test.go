@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestValidGuessForRound(t *testing.T) {
     tests := []struct {
@@ -25,4 +28,132 @@ func TestValidGuessForRound(t *testing.T) {
             t.Errorf("validGuessForRound(%d, %s): expected %v, got %v", tt.round, tt.guess, tt.expected, result)
         }
     }
-}
\ No newline at end of file
+}
+
+func TestAdvanceRoundRecordsResults(t *testing.T) {
+    s := newTestSession("a", "b")
+
+    if err := SubmitGuess(s, "a", "red"); err != nil {
+        t.Fatal(err)
+    }
+    if err := SubmitGuess(s, "b", "black"); err != nil {
+        t.Fatal(err)
+    }
+    if err := AdvanceRound(s); err != nil {
+        t.Fatal(err)
+    }
+
+    if len(s.Game.Results) != 2 {
+        t.Fatalf("expected 2 results, got %d", len(s.Game.Results))
+    }
+    for _, r := range s.Game.Results {
+        switch r.PlayerID {
+        case "a":
+            if !r.Correct || r.Given != 2 || r.Drank != 0 {
+                t.Errorf("unexpected result for a: %+v", r)
+            }
+        case "b":
+            if r.Correct || r.Drank != 2 || r.Given != 0 {
+                t.Errorf("unexpected result for b: %+v", r)
+            }
+        }
+    }
+}
+
+func TestPauseKeepsRemainingTime(t *testing.T) {
+    s := newTestSession("a")
+    start := time.Date(2026, 1, 1, 20, 0, 0, 0, time.UTC)
+    deadline := start.Add(RoundDuration)
+    s.Game.Deadline = &deadline
+
+    s.clock = start.Add(5 * time.Second)
+    if err := PauseGame(s, "host"); err != nil {
+        t.Fatal(err)
+    }
+    if err := SubmitGuess(s, "a", "red"); err == nil {
+        t.Error("expected guess to be rejected while paused")
+    }
+
+    s.clock = start.Add(time.Hour)
+    if err := ResumeGame(s, "host"); err != nil {
+        t.Fatal(err)
+    }
+    if want := s.clock.Add(RoundDuration - 5*time.Second); !s.Game.Deadline.Equal(want) {
+        t.Errorf("deadline after resume: got %v, want %v", s.Game.Deadline, want)
+    }
+}
+
+func TestRequireDrinkAckBlocksGuess(t *testing.T) {
+    s := newTestSession("a")
+    s.Settings.RequireDrinkAck = true
+    chargeDrink(s, "a", "", 2, DrinkReasonLostRound)
+
+    if err := SubmitGuess(s, "a", "red"); err == nil {
+        t.Fatal("expected guess to be blocked by pending drinks")
+    }
+    if err := AckDrinks(s, "a", nil); err != nil {
+        t.Fatal(err)
+    }
+    if err := SubmitGuess(s, "a", "red"); err != nil {
+        t.Fatalf("guess after ack: %v", err)
+    }
+}
+
+func TestEarlyEndStillOpensDistribution(t *testing.T) {
+    s := newTestSession("a", "b")
+
+    playRound(t, s, map[string]string{"a": "red", "b": "red"})
+    playRound(t, s, map[string]string{"a": "lower", "b": "lower"})
+
+    if !s.Game.DistributionActive {
+        t.Fatal("expected a distribution window for give-outs earned before everyone lost")
+    }
+    if err := DistributeDrinks(s, "a", map[string]int{"b": 2}); err != nil {
+        t.Fatalf("expected eliminated players to be valid targets: %v", err)
+    }
+}
+
+func TestDistributeEachRoundPausesBetweenRounds(t *testing.T) {
+    s := newTestSession("a", "b")
+    s.Settings.DistributeEachRound = true
+
+    playRound(t, s, map[string]string{"a": "red", "b": "red"})
+    if !s.Game.Started || !s.Game.DistributionActive || s.Game.Deadline != nil {
+        t.Fatalf("expected a between-rounds window, got %+v", s.Game)
+    }
+    if err := SubmitGuess(s, "a", "higher"); err == nil {
+        t.Error("expected guesses to wait for the window to close")
+    }
+
+    if err := DistributeDrinks(s, "a", map[string]int{"b": 2}); err != nil {
+        t.Fatal(err)
+    }
+    if err := DistributeDrinks(s, "b", map[string]int{"a": 2}); err != nil {
+        t.Fatal(err)
+    }
+    if s.Game.DistributionActive || s.Game.Deadline == nil || s.Game.Round != 1 {
+        t.Errorf("expected round 1 to start once everything was handed out, got %+v", s.Game)
+    }
+}
+
+func TestPauseBlocksDistributionUntilFinalize(t *testing.T) {
+    s := newTestSession("a", "b")
+    s.Game.Started = false
+    s.Game.DistributionActive = true
+    deadline := s.now().Add(DistributionDuration)
+    s.Game.DistributionDeadline = &deadline
+    s.Game.GiveOutRemainingByPlayer = map[string]int{"a": 2}
+
+    if err := PauseGame(s, "host"); err != nil {
+        t.Fatal(err)
+    }
+    if err := DistributeDrinks(s, "a", map[string]int{"b": 2}); err == nil || err.Error() != "game is paused" {
+        t.Fatalf("expected give-outs to wait for resume, got %v", err)
+    }
+    if err := FinalizeDistribution(s); err != nil {
+        t.Fatal(err)
+    }
+    if s.Game.Paused || s.Game.RemainingMs != 0 {
+        t.Errorf("expected finalize to clear the pause, got %+v", s.Game)
+    }
+}
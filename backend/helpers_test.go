@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+// testShared is the deal most tests play against: a red 10, then a queen,
+// a jack and a 3.
+var testShared = [4]Card{{10, Hearts}, {12, Clubs}, {11, Spades}, {3, Diamonds}}
+
+// newTestSession starts a game for the host and the given players on
+// testShared.
+func newTestSession(playerIDs ...string) *Session {
+    return newTestSessionWith(testShared, playerIDs...)
+}
+
+func newTestSessionWith(shared [4]Card, playerIDs ...string) *Session {
+    s := &Session{HostID: "host", Code: "TEST", Status: "active"}
+    s.Players = append(s.Players, Player{ID: "host", Name: "Host"})
+    for _, id := range playerIDs {
+        s.Players = append(s.Players, Player{ID: id, Name: id})
+    }
+    if err := StartGame(s); err != nil {
+        panic(err)
+    }
+    s.Game.Shared = shared
+    return s
+}
+
+// guessAll submits one guess per player.
+func guessAll(t *testing.T, s *Session, guesses map[string]string) {
+    t.Helper()
+    for pid, guess := range guesses {
+        if err := SubmitGuess(s, pid, guess); err != nil {
+            t.Fatalf("guess %s for %s: %v", guess, pid, err)
+        }
+    }
+}
+
+// playRound submits the guesses and advances the round.
+func playRound(t *testing.T, s *Session, guesses map[string]string) {
+    t.Helper()
+    guessAll(t, s, guesses)
+    if err := AdvanceRound(s); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// singleCodeLocale registers a locale whose wordlist yields only one lobby
+// code, so tests can make a code come back after its cool-down.
+func singleCodeLocale(t *testing.T) string {
+    t.Helper()
+    const locale = "zz"
+    wordlists[locale] = wordlist{Adjectives: []string{"only"}, Animals: []string{"otter"}, Verbs: []string{"waits"}}
+    t.Cleanup(func() { delete(wordlists, locale) })
+    return locale
+}
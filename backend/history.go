@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Finished games are kept well past the session TTL so a night can be
+// replayed (or argued about) the next day.
+const historyTTL = 7 * 24 * time.Hour
+
+const (
+    HistoryDeal           = "deal"
+    HistoryGuess          = "guess"
+    HistoryTapOut         = "tap_out"
+    HistoryRoundSettled   = "round_settled"
+    HistoryAllocation     = "allocation"
+    HistoryFinalizeAssign = "finalize_assign"
+    HistoryGameFinished   = "game_finished"
+    HistoryUndo           = "undo"
+    HistoryGameReopened   = "game_reopened"
+)
+
+// HistoryEntry is one line of the append-only per-lobby game log.
+type HistoryEntry struct {
+    Game        int            `json:"game"`
+    Type        string         `json:"type"`
+    At          time.Time      `json:"at"`
+    Round       int            `json:"round"`
+    PlayerID    string         `json:"playerId,omitempty"`
+    Guess       string         `json:"guess,omitempty"`
+    Correct     bool           `json:"correct,omitempty"`
+    Cards       []Card         `json:"cards,omitempty"`
+    Players     []Player       `json:"players,omitempty"`
+    Allocations map[string]int `json:"allocations,omitempty"`
+    TargetID    string         `json:"targetId,omitempty"`
+    Amount      int            `json:"amount,omitempty"`
+    Action      string         `json:"action,omitempty"`
+}
+
+func historyKey(code string) string { return "history:" + normalizeCode(code) }
+
+// logHistory stamps and queues an entry; appendHistory flushes the queue once
+// commit has persisted the mutation.
+func (s *Session) logHistory(entry HistoryEntry) {
+    entry.Game = s.GamesPlayed
+    entry.At = s.now()
+    s.history = append(s.history, entry)
+}
+
+func (s *RedisStore) appendHistory(session *Session) error {
+    if len(session.history) == 0 {
+        return nil
+    }
+    values := make([]any, 0, len(session.history))
+    for _, entry := range session.history {
+        b, err := json.Marshal(entry)
+        if err != nil {
+            return err
+        }
+        values = append(values, b)
+    }
+    key := historyKey(session.Code)
+    if err := s.rdb.RPush(s.ctx, key, values...).Err(); err != nil {
+        return err
+    }
+    session.history = nil
+    return s.rdb.Expire(s.ctx, key, historyTTL).Err()
+}
+
+// History returns the logged entries for a lobby, optionally limited to a
+// single game (game <= 0 means all games). It works after the session expired.
+// A game_finished entry later undone (game_reopened) is left out.
+func (s *RedisStore) History(code string, game int) ([]HistoryEntry, error) {
+    raw, err := s.rdb.LRange(s.ctx, historyKey(code), 0, -1).Result()
+    if err != nil {
+        return nil, err
+    }
+    entries := make([]HistoryEntry, 0, len(raw))
+    for _, line := range raw {
+        var entry HistoryEntry
+        if err := json.Unmarshal([]byte(line), &entry); err != nil {
+            continue
+        }
+        if game > 0 && entry.Game != game {
+            continue
+        }
+        if entry.Type == HistoryGameReopened {
+            for i := len(entries) - 1; i >= 0; i-- {
+                if entries[i].Type == HistoryGameFinished && entries[i].Game == entry.Game {
+                    entries = append(entries[:i], entries[i+1:]...)
+                    break
+                }
+            }
+            continue
+        }
+        entries = append(entries, entry)
+    }
+    return entries, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryReadsBackByGame(t *testing.T) {
+    store, fr := newTestStore(t)
+    s := &Session{HostID: "host", Code: "brave-red-fox", Status: "active", GamesPlayed: 1}
+
+    s.logHistory(HistoryEntry{Type: HistoryDeal, Cards: []Card{{10, Hearts}}})
+    s.logHistory(HistoryEntry{Type: HistoryGuess, PlayerID: "a", Guess: "red", Correct: true})
+    if err := store.appendHistory(s); err != nil {
+        t.Fatal(err)
+    }
+    if len(s.history) != 0 {
+        t.Fatal("expected the queue to be flushed")
+    }
+
+    s.GamesPlayed = 2
+    s.logHistory(HistoryEntry{Type: HistoryGameFinished})
+    if err := store.appendHistory(s); err != nil {
+        t.Fatal(err)
+    }
+
+    // History outlives the session it came from.
+    fr.advance(store.ttl + time.Hour)
+
+    all, err := store.History("BRAVE-RED-FOX", 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(all) != 3 || all[0].Type != HistoryDeal || all[1].Guess != "red" || all[2].Game != 2 {
+        t.Fatalf("unexpected history %+v", all)
+    }
+    first, err := store.History("BRAVE-RED-FOX", 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(first) != 2 || first[0].Game != 1 || first[1].Game != 1 {
+        t.Errorf("expected only game 1 entries, got %+v", first)
+    }
+    if none, _ := store.History("BRAVE-RED-FOX", 3); len(none) != 0 {
+        t.Errorf("expected no entries for an unplayed game, got %+v", none)
+    }
+
+    fr.advance(historyTTL)
+    if gone, _ := store.History("BRAVE-RED-FOX", 0); len(gone) != 0 {
+        t.Errorf("expected history to expire, got %+v", gone)
+    }
+}
+
+func TestRecycledCodeStartsWithEmptyHistory(t *testing.T) {
+    store, fr := newTestStore(t)
+    locale := singleCodeLocale(t)
+
+    first, host, err := store.CreateSession("Host", locale)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, _, err := store.JoinSession(first.Code, "Ann", ""); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := store.StartSession(first.Code); err != nil {
+        t.Fatal(err)
+    }
+    if old, _ := store.History(first.Code, 0); len(old) == 0 {
+        t.Fatal("expected the first lobby to log its deal")
+    }
+
+    // The session and its code's cool-down run out; the history does not.
+    fr.advance(store.ttl + CodeCooldown + time.Minute)
+    second, _, err := store.CreateSession("Host", locale)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if second.Code != first.Code || second.HostID == host.ID {
+        t.Fatalf("expected a new lobby on the same code, got %s", second.Code)
+    }
+    if got, _ := store.History(second.Code, 0); len(got) != 0 {
+        t.Errorf("expected the recycled code to start with no history, got %+v", got)
+    }
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const defaultLocale = "en"
+
+// wordlist feeds lobby codes and random host names for one locale. Words are
+// plain ASCII so codes stay easy to type on any keyboard.
+type wordlist struct {
+    Adjectives []string
+    Animals    []string
+    Verbs      []string
+}
+
+var wordlists = map[string]wordlist{
+    "en": {Adjectives: adjectives, Animals: animals, Verbs: verbs},
+    "sv": {
+        Adjectives: []string{
+            "glad", "snabb", "vild", "modig", "tyst", "stark", "lugn",
+            "pigg", "busig", "mysig", "galen", "rolig", "blank", "frisk",
+            "kvick", "listig", "ljus", "sprudlande", "kosmisk", "lysande",
+        },
+        Animals: []string{
+            "varg", "uggla", "hare", "lax", "mink", "ekorre", "igelkott",
+            "utter", "kanin", "falk", "korp", "trana", "val", "hjort",
+            "lodjur", "tupp", "get", "anka", "panda", "tiger",
+        },
+        Verbs: []string{
+            "dansar", "hoppar", "springer", "sjunger", "skrattar", "jublar",
+            "glider", "snurrar", "lyser", "festar", "vinkar", "klappar",
+            "rockar", "svajar", "myser", "simmar", "flyger", "ropar",
+        },
+    },
+}
+
+// blockedFragments must never appear in a generated code, even across word
+// boundaries ("...-KUK..." or "HORA-...").
+var blockedFragments = []string{
+    "NAZI", "KKK", "CUNT", "FAG", "RAPE", "SLUT", "KUK", "FITT", "HORA", "NEGER",
+}
+
+func supportedLocale(locale string) bool {
+    _, ok := wordlists[locale]
+    return ok
+}
+
+func wordlistFor(locale string) wordlist {
+    if wl, ok := wordlists[locale]; ok {
+        return wl
+    }
+    return wordlists[defaultLocale]
+}
+
+// acceptableCode rejects codes that repeat a word or spell something
+// offensive when the words are read together.
+func acceptableCode(words ...string) bool {
+    seen := map[string]bool{}
+    for _, w := range words {
+        if seen[w] {
+            return false
+        }
+        seen[w] = true
+    }
+    joined := strings.ToUpper(strings.Join(words, ""))
+    for _, f := range blockedFragments {
+        if strings.Contains(joined, f) {
+            return false
+        }
+    }
+    return true
+}
+
+// capitalize upper-cases the first letter; it replaces the deprecated
+// strings.Title for single words.
+func capitalize(word string) string {
+    r, size := utf8.DecodeRuneInString(word)
+    if r == utf8.RuneError {
+        return word
+    }
+    return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// acceptedLocale returns the first supported language in an Accept-Language
+// header, or "".
+func acceptedLocale(header string) string {
+    for _, part := range strings.Split(header, ",") {
+        tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+        lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+        if supportedLocale(lang) {
+            return lang
+        }
+    }
+    return ""
+}
+
+// requestLocale picks the locale for a new lobby: an explicit ?locale=, then
+// the browser's Accept-Language.
+func requestLocale(r *http.Request) string {
+    if l := strings.ToLower(r.URL.Query().Get("locale")); supportedLocale(l) {
+        return l
+    }
+    if l := acceptedLocale(r.Header.Get("Accept-Language")); l != "" {
+        return l
+    }
+    return defaultLocale
+}
+
+// codedError is a player-facing error with a stable code clients can rely on
+// and translations are keyed by. args fill the placeholders of a translated
+// message whose English text is formatted.
+type codedError struct {
+    code string
+    msg  string
+    args []any
+}
+
+func (e *codedError) Error() string { return e.msg }
+
+func newCodedError(code, msg string) error {
+    return &codedError{code: code, msg: msg}
+}
+
+// codedErrorf formats the English message; the same args fill the
+// translation.
+func codedErrorf(code, format string, args ...any) error {
+    return &codedError{code: code, msg: fmt.Sprintf(format, args...), args: args}
+}
+
+// Errors players may see; their codes key the translations below.
+var (
+    errSessionNotFound        = newCodedError("session_not_found", "session not found")
+    errSessionClosing         = newCodedError("session_closing", "session is closing")
+    errPlayerNotInSession     = newCodedError("player_not_in_session", "player not in session")
+    errPlayerIDRequired       = newCodedError("player_required", "playerId required")
+    errPlayerRequired         = newCodedError("player_required", "player required")
+    errNameRequired           = newCodedError("name_required", "name required")
+    errGameNotStarted         = newCodedError("game_not_started", "game not started")
+    errGameAlreadyStarted     = newCodedError("game_already_started", "game already started")
+    errGamePaused             = newCodedError("game_paused", "game is paused")
+    errDistributionInProgress = newCodedError("distribution_in_progress", "distribution in progress")
+    errDistributionNotActive  = newCodedError("distribution_not_active", "distribution not active")
+    errInvalidGuess           = newCodedError("invalid_guess", "invalid guess for round")
+    errGuessAlreadySubmitted  = newCodedError("guess_already_submitted", "guess already submitted for this round")
+    errPendingDrinks          = newCodedError("pending_drinks", "finish your pending drinks first")
+    errAlreadyTappedOut       = newCodedError("already_tapped_out", "player already tapped out")
+    errTapOutAlreadyRequested = newCodedError("tap_out_already_requested", "tap out already requested")
+    errNoDrinksLeft           = newCodedError("no_drinks_left", "no drinks left to give")
+    errOverAllocated          = newCodedError("over_allocated", "allocated more than available")
+    errSelfTarget             = newCodedError("self_target", "cannot give drinks to yourself")
+    errTeamTarget             = newCodedError("team_target", "cannot give drinks to your own team")
+    errInvalidTarget          = newCodedError("invalid_target", "invalid target player")
+    errUndoExpired            = newCodedError("undo_expired", "undo window has passed")
+    errNothingToUndo          = newCodedError("nothing_to_undo", "nothing to undo")
+    errHostOnlyPause          = newCodedError("host_only", "only the host can pause")
+    errHostOnlyResume         = newCodedError("host_only", "only the host can resume")
+    errHostOnlyUndo           = newCodedError("host_only", "only the host can undo")
+    errHostOnlySettings       = newCodedError("host_only", "only the host can change settings")
+    errHostOnlyTeams          = newCodedError("host_only", "only the host can manage teams")
+    errHostOnlyBots           = newCodedError("host_only", "only the host can add bots")
+    errHostOnlyJoinLinks      = newCodedError("host_only", "only the host can create join links")
+    errHostOnlyFinal          = newCodedError("host_only", "only the host can start the final")
+    errInvalidJoinLink        = newCodedError("invalid_join_link", "invalid join link")
+    errJoinLinkExpired        = newCodedError("join_link_expired", "join link expired")
+    errJoinLinkUsed           = newCodedError("join_link_used", "join link already used")
+    errProfileNotFound        = newCodedError("profile_not_found", "profile not found")
+    errTournamentNotFound     = newCodedError("tournament_not_found", "tournament not found")
+    errHistoryNotFound        = newCodedError("history_not_found", "history not found")
+    errPlayerNotActive        = newCodedError("player_not_active", "player not active")
+    errRideBeforeGuess        = newCodedError("ride_before_guess", "let it ride before guessing")
+    errNothingToRide          = newCodedError("nothing_to_ride", "nothing banked to ride")
+    errBetBetweenRounds       = newCodedError("bet_between_rounds", "wait for the next round to bet")
+    errBetOnSelf              = newCodedError("bet_on_self", "cannot bet on yourself")
+    errBetTargetInactive      = newCodedError("bet_target_inactive", "can only bet on active players")
+    errBetTargetGuessed       = newCodedError("bet_target_guessed", "player already guessed this round")
+    errBetAlreadyPlaced       = newCodedError("bet_already_placed", "bet already placed on this player")
+    errNegativeTapOutPenalty  = newCodedError("invalid_tap_out_penalty", "tap-out penalty must not be negative")
+    errTapOutCancelDisabled   = newCodedError("tap_out_cancel_disabled", "tap-outs cannot be cancelled in this lobby")
+    errNoTapOutPending        = newCodedError("no_tap_out_pending", "no tap out pending")
+    errTeamsLocked            = newCodedError("teams_locked", "teams can only change between games")
+    errTeamNameRequired       = newCodedError("team_name_required", "team name required")
+    errTeamIDRequired         = newCodedError("team_id_required", "team id required")
+    errTeamNameTaken          = newCodedError("team_name_taken", "team name already taken")
+    errTeamNotFound           = newCodedError("team_not_found", "team not found")
+    errNegativeAdvance        = newCodedError("invalid_advance", "advancePerTable must not be negative")
+    errSeatingClosed          = newCodedError("seating_closed", "seating is closed")
+    errFinalStarted           = newCodedError("final_already_started", "final already started")
+    errNotEnoughFinalists     = newCodedError("not_enough_finalists", "not enough players for a final")
+    errGameAlreadyPaused      = newCodedError("game_already_paused", "game already paused")
+    errNothingToPause         = newCodedError("nothing_to_pause", "nothing to pause")
+    errGameNotPaused          = newCodedError("game_not_paused", "game not paused")
+    errGameAlreadyFinished    = newCodedError("game_already_finished", "game already finished")
+    errNoAllocation           = newCodedError("no_allocation", "no allocation provided")
+    errNameClaimed            = newCodedError("name_claimed", "name already claimed")
+    errInvalidMetric          = newCodedError("invalid_leaderboard_metric", "invalid leaderboard metric")
+    errInvalidWindow          = newCodedError("invalid_leaderboard_window", "invalid leaderboard window")
+)
+
+var translations = map[string]map[string]string{
+    "sv": {
+        "session_not_found":         "Lobbyn hittades inte",
+        "session_closing":           "Lobbyn håller på att stängas",
+        "lobby_ended":               "Den här lobbyn har avslutats",
+        "player_not_in_session":     "Spelaren finns inte i lobbyn",
+        "player_required":           "Spelare saknas",
+        "name_required":             "Namn krävs",
+        "game_not_started":          "Spelet har inte startat",
+        "game_already_started":      "Spelet har redan startat",
+        "game_paused":               "Spelet är pausat",
+        "distribution_in_progress":  "Utdelning pågår",
+        "distribution_not_active":   "Ingen utdelning pågår",
+        "invalid_guess":             "Ogiltig gissning för den här rundan",
+        "guess_already_submitted":   "Du har redan gissat den här rundan",
+        "pending_drinks":            "Drick upp det du är skyldig först",
+        "already_tapped_out":        "Du har redan hoppat av",
+        "tap_out_already_requested": "Du har redan begärt att hoppa av",
+        "no_drinks_left":            "Du har inga klunkar kvar att dela ut",
+        "over_allocated":            "Du delade ut fler än du har",
+        "self_target":               "Du kan inte ge klunkar till dig själv",
+        "team_target":               "Du kan inte ge klunkar till ditt eget lag",
+        "invalid_target":            "Ogiltig mottagare",
+        "undo_expired":              "Det är för sent att ångra",
+        "nothing_to_undo":           "Det finns inget att ångra",
+        "host_only":                 "Bara värden kan göra det",
+        "profile_not_found":         "Profilen hittades inte",
+        "tournament_not_found":      "Turneringen hittades inte",
+        "history_not_found":         "Historiken hittades inte",
+        "player_not_active":         "Spelaren är inte med i rundan",
+        "ride_before_guess":         "Satsa allt innan du gissar",
+        "nothing_to_ride":           "Du har inget sparat att satsa",
+        "bet_between_rounds":        "Vänta till nästa runda för att satsa",
+        "bet_on_self":               "Du kan inte satsa på dig själv",
+        "bet_target_inactive":       "Du kan bara satsa på spelare som är kvar",
+        "bet_target_guessed":        "Spelaren har redan gissat den här rundan",
+        "bet_already_placed":        "Du har redan satsat på den spelaren",
+        "invalid_tap_out_penalty":   "Avhoppsstraffet får inte vara negativt",
+        "tap_out_cancel_disabled":   "Avhopp kan inte ångras i den här lobbyn",
+        "no_tap_out_pending":        "Du har inte begärt att hoppa av",
+        "teams_locked":              "Lagen kan bara ändras mellan spelen",
+        "team_name_required":        "Lagnamn krävs",
+        "team_id_required":          "Lag-id krävs",
+        "team_name_taken":           "Lagnamnet är redan taget",
+        "team_not_found":            "Laget hittades inte",
+        "invalid_advance":           "Antalet som går vidare får inte vara negativt",
+        "seating_closed":            "Platserna är redan fördelade",
+        "final_already_started":     "Finalen har redan startat",
+        "not_enough_finalists":      "För få spelare för en final",
+        "game_already_paused":       "Spelet är redan pausat",
+        "nothing_to_pause":          "Det finns inget att pausa",
+        "game_not_paused":           "Spelet är inte pausat",
+        "game_already_finished":     "Spelet är redan slut",
+        "no_allocation":             "Du har inte delat ut något",
+        "name_claimed":              "Namnet är redan upptaget",
+        "invalid_leaderboard_metric": "Okänt mått för topplistan",
+        "invalid_leaderboard_window": "Okänd period för topplistan",
+        "bet_amount":                "Insatsen måste vara mellan 1 och %d",
+        "invalid_table_count":       "Antalet bord måste vara mellan 1 och %d",
+        "table_playing":             "Bord %s spelar fortfarande",
+        "invalid_join_link":         "Ogiltig inbjudningslänk",
+        "join_link_expired":         "Inbjudningslänken har gått ut",
+        "join_link_used":            "Inbjudningslänken har redan använts",
+        "protected_target":          "%s är skyddad och kan inte få klunkar",
+        "share_limit":               "Du kan ge högst %d till %s (%d%% av dina utdelningar); %d redan givna",
+    },
+}
+
+// localize returns an error's code and its message in the locale. Errors
+// without a code or a translation keep their own message.
+func localize(locale string, err error) (string, string) {
+    var coded *codedError
+    if !errors.As(err, &coded) {
+        return "error", err.Error()
+    }
+    t, ok := translations[locale][coded.code]
+    if !ok {
+        return coded.code, err.Error()
+    }
+    if len(coded.args) > 0 {
+        t = fmt.Sprintf(t, coded.args...)
+    }
+    return coded.code, t
+}
+
+// localizedError writes an error in the caller's language: Accept-Language
+// first, then the lobby's locale setting. The stable code is sent in the
+// X-Error-Code header.
+func localizedError(w http.ResponseWriter, r *http.Request, store sessionGetter, code string, err error, status int) {
+    locale := ""
+    if code != "" {
+        if session, ok := store.GetSession(code); ok {
+            locale = session.Settings.Locale
+        }
+    }
+    writeLocalizedError(w, r, locale, err, status)
+}
+
+// tournamentError is localizedError for tournament routes, falling back to
+// the tournament's locale.
+func tournamentError(w http.ResponseWriter, r *http.Request, store *RedisStore, code string, err error, status int) {
+    locale := ""
+    if t, ok := store.GetTournament(code); ok {
+        locale = t.Locale
+    }
+    writeLocalizedError(w, r, locale, err, status)
+}
+
+func writeLocalizedError(w http.ResponseWriter, r *http.Request, fallback string, err error, status int) {
+    locale := acceptedLocale(r.Header.Get("Accept-Language"))
+    if locale == "" {
+        locale = fallback
+    }
+    errCode, text := localize(locale, err)
+    w.Header().Set("X-Error-Code", errCode)
+    http.Error(w, text, status)
+}
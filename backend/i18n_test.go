@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSwedishCodesAndMessages(t *testing.T) {
+    code, err := generateLobbyCode("sv")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(strings.Split(code, "-")) != 3 {
+        t.Errorf("expected three words, got %q", code)
+    }
+    if acceptableCode("glad", "glad", "dansar") || acceptableCode("snabb", "hora", "x") {
+        t.Error("expected repeated words and blocked fragments to be rejected")
+    }
+    if got := acceptedLocale("sv-SE,sv;q=0.9,en;q=0.8"); got != "sv" {
+        t.Errorf("expected sv from Accept-Language, got %q", got)
+    }
+    if errCode, msg := localize("sv", errGamePaused); errCode != "game_paused" || msg != "Spelet är pausat" {
+        t.Errorf("unexpected translation %q %q", errCode, msg)
+    }
+    if capitalize("älg") != "Älg" {
+        t.Error("expected capitalize to handle non-ASCII letters")
+    }
+}
+
+func TestLocalizeUsesTheErrorsCode(t *testing.T) {
+    s := newTestSession("a", "b")
+    s.Settings.Targeting.Protected = []string{"b"}
+
+    err := checkTargeting(s, "a", map[string]int{"b": 1})
+    if errCode, msg := localize("sv", fmt.Errorf("give-out: %w", err)); errCode != "protected_target" || msg != "b är skyddad och kan inte få klunkar" {
+        t.Errorf("unexpected translation %q %q", errCode, msg)
+    }
+    if errCode, msg := localize("sv", errors.New("game is paused")); errCode != "error" || msg != "game is paused" {
+        t.Errorf("expected an uncoded error to pass through, got %q %q", errCode, msg)
+    }
+}
+
+func TestTournamentErrorsAreLocalized(t *testing.T) {
+    store, _ := newTestStore(t)
+    tr, err := store.CreateTournament("Host", "Cup", "sv", 1, 2)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    w := httptest.NewRecorder()
+    tournamentError(w, httptest.NewRequest(http.MethodPost, "/", nil), store, tr.Code, errTournamentNotFound, http.StatusNotFound)
+    if got := w.Header().Get("X-Error-Code"); got != "tournament_not_found" {
+        t.Errorf("expected the error code header, got %q", got)
+    }
+    if got := strings.TrimSpace(w.Body.String()); got != "Turneringen hittades inte" {
+        t.Errorf("expected the tournament's locale, got %q", got)
+    }
+}
+
+func TestRuleErrorsAreTranslated(t *testing.T) {
+    s := newTestSession("a", "b")
+
+    cases := []struct {
+        err  error
+        code string
+        msg  string
+    }{
+        {PlaceSideBet(s, SideBet{BettorID: "a", TargetID: "a", Amount: 1}), "bet_on_self", "Du kan inte satsa på dig själv"},
+        {PlaceSideBet(s, SideBet{BettorID: "a", TargetID: "b", Amount: 9}), "bet_amount", "Insatsen måste vara mellan 1 och 5"},
+        {CreateTeam(s, "host", Team{ID: "t1", Name: "Röd"}), "teams_locked", "Lagen kan bara ändras mellan spelen"},
+        {ResumeGame(s, "host"), "game_not_paused", "Spelet är inte pausat"},
+    }
+    for _, c := range cases {
+        if errCode, msg := localize("sv", c.err); errCode != c.code || msg != c.msg {
+            t.Errorf("expected %q %q, got %q %q", c.code, c.msg, errCode, msg)
+        }
+    }
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Besides the word code, a lobby can be reached through a short numeric PIN
+// reserved in Redis, or a signed one-time join link for the QR code.
+// normalizeCode resolves both to the word code.
+
+const (
+    minPINDigits       = 4
+    maxPINDigits       = 6
+    pinAttemptsPerSize = 8
+
+    joinTokenPrefix = "j_"
+    JoinLinkTTL     = 30 * time.Minute
+)
+
+func pinKey(pin string) string         { return "pin:" + pin }
+func joinTokenKey(nonce string) string { return "jointoken:" + nonce }
+
+var joinLinkSecret = loadJoinLinkSecret()
+
+// loadJoinLinkSecret reads JOIN_LINK_SECRET. Without it links are signed with
+// a per-process key and stop working after a restart. Signing with an
+// all-zero key would make links forgeable, so failing to make one is fatal.
+func loadJoinLinkSecret() []byte {
+    if secret := os.Getenv("JOIN_LINK_SECRET"); secret != "" {
+        return []byte(secret)
+    }
+    b := make([]byte, 32)
+    if _, err := rand.Read(b); err != nil {
+        log.Fatalf("join links: no secret available: %v", err)
+    }
+    return b
+}
+
+func isPIN(code string) bool {
+    if len(code) < minPINDigits || len(code) > maxPINDigits {
+        return false
+    }
+    for _, r := range code {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
+
+func generatePIN(digits int) (string, error) {
+    pin := make([]byte, digits)
+    for i := range pin {
+        d, err := cryptoIndex(10)
+        if err != nil {
+            return "", err
+        }
+        pin[i] = byte('0' + d)
+    }
+    return string(pin), nil
+}
+
+// reservePIN claims the shortest free PIN for the lobby, growing from four to
+// six digits as PINs get crowded.
+func (s *RedisStore) reservePIN(code string) (string, error) {
+    for digits := minPINDigits; digits <= maxPINDigits; digits++ {
+        for i := 0; i < pinAttemptsPerSize; i++ {
+            pin, err := generatePIN(digits)
+            if err != nil {
+                return "", err
+            }
+            ok, err := s.rdb.SetNX(s.ctx, pinKey(pin), code, s.ttl).Result()
+            if err != nil {
+                return "", err
+            }
+            if ok {
+                return pin, nil
+            }
+        }
+    }
+    return "", errors.New("unable to reserve a PIN")
+}
+
+// resolveCode turns a typed PIN into its lobby's word code. It runs once where
+// a code enters through HTTP; normalizeCode never touches Redis.
+func (s *RedisStore) resolveCode(code string) string {
+    code = strings.TrimSpace(code)
+    if isPIN(code) {
+        if resolved, ok := s.lookupPIN(code); ok {
+            return resolved
+        }
+    }
+    return code
+}
+
+func (s *RedisStore) lookupPIN(pin string) (string, bool) {
+    code, err := s.rdb.Get(s.ctx, pinKey(pin)).Result()
+    if err != nil {
+        return "", false
+    }
+    return code, true
+}
+
+func signJoinToken(payload string) string {
+    mac := hmac.New(sha256.New, joinLinkSecret)
+    mac.Write([]byte(payload))
+    return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// newJoinToken returns a signed token naming the lobby, a nonce and an
+// expiry.
+func newJoinToken(code string, now time.Time) (string, time.Time, error) {
+    nonce, err := newUUIDv4()
+    if err != nil {
+        return "", time.Time{}, err
+    }
+    expires := now.Add(JoinLinkTTL)
+    payload := fmt.Sprintf("%s|%s|%d", code, nonce, expires.Unix())
+    token := joinTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signJoinToken(payload)
+    return token, expires, nil
+}
+
+// parseJoinToken checks the signature and expiry, returning the lobby code
+// and the nonce that makes the token single-use.
+func parseJoinToken(token string, now time.Time) (string, string, error) {
+    body, sig, ok := strings.Cut(strings.TrimPrefix(token, joinTokenPrefix), ".")
+    if !ok || !strings.HasPrefix(token, joinTokenPrefix) {
+        return "", "", errInvalidJoinLink
+    }
+    raw, err := base64.RawURLEncoding.DecodeString(body)
+    if err != nil {
+        return "", "", errInvalidJoinLink
+    }
+    payload := string(raw)
+    if !hmac.Equal([]byte(sig), []byte(signJoinToken(payload))) {
+        return "", "", errInvalidJoinLink
+    }
+    parts := strings.Split(payload, "|")
+    if len(parts) != 3 {
+        return "", "", errInvalidJoinLink
+    }
+    expires, err := strconv.ParseInt(parts[2], 10, 64)
+    if err != nil || now.Unix() > expires {
+        return "", "", errJoinLinkExpired
+    }
+    return parts[0], parts[1], nil
+}
+
+// consumeJoinToken resolves a join link and marks it used.
+func (s *RedisStore) consumeJoinToken(token string) (string, error) {
+    code, nonce, err := parseJoinToken(token, time.Now())
+    if err != nil {
+        return "", err
+    }
+    ok, err := s.rdb.SetNX(s.ctx, joinTokenKey(nonce), code, JoinLinkTTL).Result()
+    if err != nil {
+        return "", err
+    }
+    if !ok {
+        return "", errJoinLinkUsed
+    }
+    return code, nil
+}
+
+// JoinLink issues a fresh one-time join token. Only the host may hand them
+// out.
+func (s *RedisStore) JoinLink(code, requesterID string) (string, time.Time, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return "", time.Time{}, errSessionNotFound
+    }
+    if requesterID != session.HostID {
+        return "", time.Time{}, errHostOnlyJoinLinks
+    }
+    if session.Status != "active" {
+        return "", time.Time{}, errSessionClosing
+    }
+    return newJoinToken(session.Code, time.Now())
+}
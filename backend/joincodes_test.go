@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJoinTokensAndPINs(t *testing.T) {
+    now := time.Now()
+    token, expires, err := newJoinToken("BRAVE-RED-FOX", now)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !expires.After(now) {
+        t.Error("expected expiry in the future")
+    }
+    if code, nonce, err := parseJoinToken(token, now); err != nil || code != "BRAVE-RED-FOX" || nonce == "" {
+        t.Fatalf("unexpected parse %q %q %v", code, nonce, err)
+    }
+    if normalizeCode(token) != "BRAVE-RED-FOX" {
+        t.Error("expected join links to normalize to the lobby code")
+    }
+    if _, _, err := parseJoinToken(token+"x", now); err == nil {
+        t.Error("expected a tampered token to be rejected")
+    }
+    if _, _, err := parseJoinToken(token, now.Add(JoinLinkTTL+time.Minute)); err == nil {
+        t.Error("expected an expired token to be rejected")
+    }
+
+    pin, err := generatePIN(minPINDigits)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !isPIN(pin) || isPIN("123") || isPIN("1234567") || isPIN("12A4") {
+        t.Error("unexpected PIN validation")
+    }
+}
+
+func TestPINsResolveOnlyAtTheBoundary(t *testing.T) {
+    store, _ := newTestStore(t)
+    session, _, err := store.CreateSession("Host", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if session.PIN == "" {
+        t.Fatal("expected the lobby to get a PIN")
+    }
+
+    if got := normalizeCode(session.PIN); got != session.PIN {
+        t.Errorf("expected normalizeCode to leave PINs alone, got %q", got)
+    }
+    code := store.resolveCode(" " + session.PIN + " ")
+    if code != session.Code {
+        t.Fatalf("expected the PIN to resolve to %s, got %q", session.Code, code)
+    }
+    if _, _, err := store.JoinSession(code, "Ann", ""); err != nil {
+        t.Fatalf("join by resolved PIN: %v", err)
+    }
+    if got := store.resolveCode("0000000"); got != "0000000" {
+        t.Errorf("expected a non-PIN to pass through, got %q", got)
+    }
+}
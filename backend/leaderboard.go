@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+const (
+    MetricGiven   = "given"
+    MetricDrank   = "drank"
+    MetricPerfect = "perfect"
+
+    WindowAllTime = "alltime"
+    WindowWeekly  = "weekly"
+)
+
+// Weekly boards are kept a while after the week ends so "last week" still
+// resolves, then expire on their own.
+const weeklyLeaderboardTTL = 5 * 7 * 24 * time.Hour
+
+var leaderboardMetrics = []string{MetricGiven, MetricDrank, MetricPerfect}
+
+type LeaderboardEntry struct {
+    Rank      int    `json:"rank"`
+    ProfileID string `json:"profileId,omitempty"`
+    PlayerID  string `json:"playerId,omitempty"`
+    Name      string `json:"name"`
+    Score     int    `json:"score"`
+}
+
+func validLeaderboardMetric(metric string) bool {
+    for _, m := range leaderboardMetrics {
+        if m == metric {
+            return true
+        }
+    }
+    return false
+}
+
+func weekLabel(t time.Time) string {
+    year, week := t.ISOWeek()
+    return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+func leaderboardKey(window, period, metric string) string {
+    if window == WindowWeekly {
+        return "leaderboard:" + WindowWeekly + ":" + period + ":" + metric
+    }
+    return "leaderboard:" + WindowAllTime + ":" + metric
+}
+
+// playerMetrics returns a player's score for each metric, for one game or,
+// with game <= 0, for the whole session.
+func playerMetrics(s *Session, p Player, game int) map[string]int {
+    perfect := p.PerfectRuns
+    if game > 0 {
+        perfect = 0
+        if p.LastPerfectGame == game {
+            perfect = 1
+        }
+    }
+    return map[string]int{
+        MetricGiven:   drinksGiven(s, p.ID, game),
+        MetricDrank:   drinksTaken(s, p.ID, game),
+        MetricPerfect: perfect,
+    }
+}
+
+// SessionLeaderboard ranks the players of a single session by metric.
+func SessionLeaderboard(s *Session, metric string) ([]LeaderboardEntry, error) {
+    if s == nil {
+        return nil, errors.New("session required")
+    }
+    if !validLeaderboardMetric(metric) {
+        return nil, errInvalidMetric
+    }
+
+    entries := make([]LeaderboardEntry, 0, len(s.Players))
+    for _, p := range s.Players {
+        if p.ID == s.HostID {
+            continue
+        }
+        entries = append(entries, LeaderboardEntry{
+            ProfileID: p.ProfileID,
+            PlayerID:  p.ID,
+            Name:      p.Name,
+            Score:     playerMetrics(s, p, 0)[metric],
+        })
+    }
+    sort.SliceStable(entries, func(i, j int) bool {
+        return entries[i].Score > entries[j].Score
+    })
+    for i := range entries {
+        entries[i].Rank = i + 1
+    }
+    return entries, nil
+}
+
+// bumpLeaderboards incrementally adds a finished game to the all-time and
+// weekly sorted sets of the player's profile; sign -1 takes it back out.
+func (s *RedisStore) bumpLeaderboards(g profileGame, sign int) error {
+    if g.ProfileID == "" {
+        return nil
+    }
+
+    _, err := s.rdb.TxPipelined(s.ctx, func(pipe redis.Pipeliner) error {
+        for _, metric := range leaderboardMetrics {
+            score := g.Metrics[metric]
+            if score == 0 {
+                continue
+            }
+            allTime := leaderboardKey(WindowAllTime, "", metric)
+            weekly := leaderboardKey(WindowWeekly, g.Period, metric)
+            pipe.ZIncrBy(s.ctx, allTime, float64(sign*score), g.ProfileID)
+            pipe.ZIncrBy(s.ctx, weekly, float64(sign*score), g.ProfileID)
+            pipe.Expire(s.ctx, weekly, weeklyLeaderboardTTL)
+            if sign < 0 {
+                // Nobody belongs on a board with nothing to show.
+                pipe.ZRemRangeByScore(s.ctx, allTime, "-inf", "0")
+                pipe.ZRemRangeByScore(s.ctx, weekly, "-inf", "0")
+            }
+        }
+        return nil
+    })
+    return err
+}
+
+// Leaderboard reads the top entries of a profile board. For the weekly
+// window an empty period means the current week.
+func (s *RedisStore) Leaderboard(window, period, metric string, limit int) ([]LeaderboardEntry, error) {
+    if !validLeaderboardMetric(metric) {
+        return nil, errInvalidMetric
+    }
+    if window != WindowAllTime && window != WindowWeekly {
+        return nil, errInvalidWindow
+    }
+    if window == WindowWeekly && period == "" {
+        period = weekLabel(time.Now().UTC())
+    }
+    if limit <= 0 || limit > 100 {
+        limit = 10
+    }
+
+    rows, err := s.rdb.ZRevRangeWithScores(s.ctx, leaderboardKey(window, period, metric), 0, int64(limit-1)).Result()
+    if err != nil {
+        return nil, err
+    }
+
+    entries := make([]LeaderboardEntry, 0, len(rows))
+    for i, row := range rows {
+        id, _ := row.Member.(string)
+        entry := LeaderboardEntry{Rank: i + 1, ProfileID: id, Score: int(row.Score)}
+        if profile, ok := s.GetProfile(id); ok {
+            entry.Name = profile.Name
+        }
+        entries = append(entries, entry)
+    }
+    return entries, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaderboardsCountEachGameOnce(t *testing.T) {
+    store, _ := newTestStore(t)
+    profile, _, err := store.CreateProfile("Ada", false)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    s := newTestSession("a", "b")
+    findPlayer(s, "a").ProfileID = profile.ID
+    week1 := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+    week2 := week1.Add(7 * 24 * time.Hour)
+
+    chargeDrink(s, "a", "", 2, DrinkReasonLostRound)
+    chargeDrink(s, "b", "a", 3, DrinkReasonGiven)
+    if err := store.bumpLeaderboards(newProfileGame(s, *findPlayer(s, "a"), week1), 1); err != nil {
+        t.Fatal(err)
+    }
+
+    // A second game in the same lobby adds only its own drinks.
+    s.GamesPlayed++
+    chargeDrink(s, "a", "", 4, DrinkReasonLostRound)
+    if err := store.bumpLeaderboards(newProfileGame(s, *findPlayer(s, "a"), week2), 1); err != nil {
+        t.Fatal(err)
+    }
+
+    scores := func(window, period, metric string) []LeaderboardEntry {
+        t.Helper()
+        entries, err := store.Leaderboard(window, period, metric, 10)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return entries
+    }
+    if got := scores(WindowAllTime, "", MetricDrank); len(got) != 1 || got[0].Score != 6 || got[0].Name != "Ada" {
+        t.Errorf("expected 2+4 drank all-time, got %+v", got)
+    }
+    if got := scores(WindowAllTime, "", MetricGiven); len(got) != 1 || got[0].Score != 3 {
+        t.Errorf("expected 3 given all-time, got %+v", got)
+    }
+    if got := scores(WindowWeekly, weekLabel(week1), MetricDrank); len(got) != 1 || got[0].Score != 2 {
+        t.Errorf("expected 2 drank in week 1, got %+v", got)
+    }
+    if got := scores(WindowWeekly, weekLabel(week2), MetricDrank); len(got) != 1 || got[0].Score != 4 {
+        t.Errorf("expected 4 drank in week 2, got %+v", got)
+    }
+    if got := scores(WindowWeekly, weekLabel(week2), MetricGiven); len(got) != 0 {
+        t.Errorf("expected nothing given in week 2, got %+v", got)
+    }
+    if _, err := store.Leaderboard("monthly", "", MetricDrank, 10); err == nil {
+        t.Error("expected an unknown window to be rejected")
+    }
+}
+
+func TestSessionLeaderboardSpansGames(t *testing.T) {
+    s := newTestSession("a", "b")
+    chargeDrink(s, "a", "", 2, DrinkReasonLostRound)
+    s.GamesPlayed++
+    chargeDrink(s, "a", "", 1, DrinkReasonLostRound)
+    chargeDrink(s, "b", "", 2, DrinkReasonLostRound)
+
+    entries, err := SessionLeaderboard(s, MetricDrank)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(entries) != 2 || entries[0].PlayerID != "a" || entries[0].Score != 3 || entries[1].Score != 2 {
+        t.Errorf("unexpected session leaderboard %+v", entries)
+    }
+}
+
+func TestPerfectRunsAddUpAcrossGames(t *testing.T) {
+    s := newTestSession("a", "b")
+    perfectGame := func() {
+        t.Helper()
+        playRound(t, s, map[string]string{"a": "red", "b": "black"})
+        for _, guess := range []string{"higher", "between", "diamonds"} {
+            // Leftover give-outs are not what this test is about.
+            s.Game.GiveOutRemainingByPlayer = map[string]int{}
+            playRound(t, s, map[string]string{"a": guess})
+        }
+        if s.Game.Started {
+            t.Fatalf("expected the game to be over, got %+v", s.Game)
+        }
+    }
+    restart := func() {
+        t.Helper()
+        if err := StartGame(s); err != nil {
+            t.Fatal(err)
+        }
+        s.Game.Shared = testShared
+    }
+
+    perfectGame()
+    restart()
+    perfectGame()
+    if got := newProfileGame(s, *findPlayer(s, "a"), time.Now()).Metrics[MetricPerfect]; got != 1 {
+        t.Errorf("expected the game to add one perfect run to the profile, got %d", got)
+    }
+    restart()
+
+    entries, err := SessionLeaderboard(s, MetricPerfect)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if entries[0].PlayerID != "a" || entries[0].Score != 2 || entries[1].Score != 0 {
+        t.Errorf("expected two perfect runs for a after a new start, got %+v", entries)
+    }
+}
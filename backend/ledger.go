@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+    DrinkReasonLostRound = "lost_round"
+    DrinkReasonGiven     = "given"
+    DrinkReasonLeftover  = "leftover"
+)
+
+// LedgerEntry is one drink assignment with its provenance. The ledger is the
+// only place drinks are recorded; DrinkNowByPlayer, Player.Score,
+// LifetimeDrank and GivenOut are all derived from it by recountDrinks.
+// Entries not yet acknowledged by the drinker form their pending queue.
+type LedgerEntry struct {
+    ID     string    `json:"id"`
+    FromID string    `json:"fromId,omitempty"` // empty when the game itself assigned it
+    ToID   string    `json:"toId"`
+    Amount int       `json:"amount"`
+    Reason string    `json:"reason"`
+    Game   int       `json:"game"`
+    Round  int       `json:"round"`
+    At     time.Time `json:"at"`
+    Acked  bool      `json:"acked"`
+}
+
+// chargeDrink records a drink in the ledger and refreshes the derived
+// counters. Safety caps are applied first: overflow from a giver is
+// redirected when the lobby asks for it, anything else over the cap is
+// dropped. Consecutive sips with the same origin (finalize hands them out
+// one at a time) are merged into a single entry. It returns what toID was
+// actually charged.
+func chargeDrink(s *Session, toID, fromID string, amount int, reason string) int {
+    if amount <= 0 {
+        return 0
+    }
+    amount, overflow := applyDrinkCap(s, toID, amount)
+    if amount > 0 {
+        appendLedger(s, toID, fromID, amount, reason)
+        recountDrinks(s)
+        checkBAC(s, toID)
+    }
+    if overflow > 0 && fromID != "" && s.Settings.Safety.OnCap == CapActionRedirect {
+        if target := redirectTarget(s, toID, fromID); target != "" {
+            chargeDrink(s, target, fromID, overflow, DrinkReasonRedirected)
+        }
+    }
+    return amount
+}
+
+func appendLedger(s *Session, toID, fromID string, amount int, reason string) {
+    if n := len(s.Ledger); n > 0 {
+        last := &s.Ledger[n-1]
+        if !last.Acked && last.ToID == toID && last.FromID == fromID && last.Reason == reason &&
+            last.Game == s.GamesPlayed && last.Round == s.Game.Round {
+            last.Amount += amount
+            return
+        }
+    }
+    s.DrinkSeq++
+    s.Ledger = append(s.Ledger, LedgerEntry{
+        ID:     "drink_" + strconv.Itoa(s.DrinkSeq),
+        FromID: fromID,
+        ToID:   toID,
+        Amount: amount,
+        Reason: reason,
+        Game:   s.GamesPlayed,
+        Round:  s.Game.Round,
+        At:     s.now(),
+    })
+}
+
+// givenByGiver reports whether the entry counts toward its giver's GivenOut.
+func givenByGiver(e LedgerEntry) bool {
+    return e.FromID != "" && (e.Reason == DrinkReasonGiven || e.Reason == DrinkReasonRedirected)
+}
+
+// recountDrinks rebuilds every drink counter from the ledger.
+func recountDrinks(s *Session) {
+    drinkNow := map[string]int{}
+    drank := map[string]int{}
+    given := map[string]int{}
+    for _, e := range s.Ledger {
+        drank[e.ToID] += e.Amount
+        if e.Game == s.GamesPlayed {
+            drinkNow[e.ToID] += e.Amount
+        }
+        if givenByGiver(e) {
+            given[e.FromID] += e.Amount
+        }
+    }
+    for i := range s.Players {
+        p := &s.Players[i]
+        p.Score = drank[p.ID]
+        p.LifetimeDrank = drank[p.ID]
+        p.GivenOut = given[p.ID]
+    }
+    s.Game.DrinkNowByPlayer = drinkNow
+}
+
+// drinksTaken sums what the player drank in one game (game <= 0: all games).
+func drinksTaken(s *Session, playerID string, game int) int {
+    total := 0
+    for _, e := range s.Ledger {
+        if e.ToID == playerID && (game <= 0 || e.Game == game) {
+            total += e.Amount
+        }
+    }
+    return total
+}
+
+// drinksGiven sums what the player actively handed out in one game
+// (game <= 0: all games).
+func drinksGiven(s *Session, playerID string, game int) int {
+    total := 0
+    for _, e := range s.Ledger {
+        if e.FromID == playerID && givenByGiver(e) && (game <= 0 || e.Game == game) {
+            total += e.Amount
+        }
+    }
+    return total
+}
+
+func pendingDrinkTotal(s *Session, playerID string) int {
+    total := 0
+    for _, e := range s.Ledger {
+        if e.ToID == playerID && !e.Acked {
+            total += e.Amount
+        }
+    }
+    return total
+}
+
+// AckDrinks marks the given ledger entries of the player as drunk; with no
+// IDs it acknowledges the player's whole pending queue.
+func AckDrinks(s *Session, playerID string, ids []string) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if !hasPlayer(s, playerID) {
+        return errPlayerNotInSession
+    }
+
+    wanted := map[string]bool{}
+    for _, id := range ids {
+        wanted[id] = true
+    }
+
+    acked := 0
+    for i := range s.Ledger {
+        e := &s.Ledger[i]
+        if e.ToID != playerID || e.Acked {
+            continue
+        }
+        if len(wanted) == 0 || wanted[e.ID] {
+            e.Acked = true
+            acked++
+        }
+    }
+    if acked == 0 {
+        return errors.New("no pending drinks to acknowledge")
+    }
+    return nil
+}
+
+// LedgerFor returns the entries the player received or handed out.
+func LedgerFor(s *Session, playerID string) []LedgerEntry {
+    out := []LedgerEntry{}
+    for _, e := range s.Ledger {
+        if e.ToID == playerID || e.FromID == playerID {
+            out = append(out, e)
+        }
+    }
+    return out
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestLedgerDrivesDrinkCounters(t *testing.T) {
+    s := newTestSession("a", "b")
+
+    chargeDrink(s, "a", "", 2, DrinkReasonLostRound)
+    chargeDrink(s, "a", "b", 3, DrinkReasonGiven)
+    chargeDrink(s, "a", "b", 1, DrinkReasonLeftover)
+    chargeDrink(s, "a", "b", 1, DrinkReasonLeftover)
+
+    if len(s.Ledger) != 3 {
+        t.Fatalf("expected leftover sips to merge into 3 entries, got %d", len(s.Ledger))
+    }
+    a, b := findPlayer(s, "a"), findPlayer(s, "b")
+    if a.LifetimeDrank != 7 || a.Score != 7 || s.Game.DrinkNowByPlayer["a"] != 7 {
+        t.Errorf("unexpected totals for a: %+v, drinkNow %d", a, s.Game.DrinkNowByPlayer["a"])
+    }
+    if b.GivenOut != 3 {
+        t.Errorf("expected b to have given 3, got %d", b.GivenOut)
+    }
+}
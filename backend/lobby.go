@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -31,15 +32,14 @@ func main() {
     }
 
     hub.ConfigureIdleClose(15*time.Minute, func(code string) {
+        if strings.HasPrefix(code, normalizeCode(tournamentChannel(""))) {
+            return // tournament rooms have no lobby to close
+        }
         session, err := store.CloseSession(code, 30*time.Second)
         if err != nil {
             return
         }
-        if bus != nil {
-            _ = bus.PublishSession(ctx, session)
-        } else {
-            hub.broadcastSession(session.Code, session)
-        }
+        publishSession(ctx, session, bus, hub)
         log.Printf("lobby %s auto-closed after WS inactivity", code)
     })
 
@@ -64,17 +64,13 @@ func main() {
             return
         }
 
-        session, host, err := store.CreateSession("") // default host name in store
+        session, host, err := store.CreateSession("", requestLocale(r)) // default host name in store
         if err != nil {
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+            writeLocalizedError(w, r, requestLocale(r), err, http.StatusInternalServerError)
             return
         }
 
-        if bus != nil {
-            _ = bus.PublishSession(ctx, session)
-        } else {
-            hub.broadcastSession(session.Code, session)
-        }
+        publishSession(ctx, session, bus, hub)
 
         writeJSON(w, http.StatusCreated, map[string]any{
             "hostId":  host.ID,
@@ -95,7 +91,7 @@ func main() {
             http.NotFound(w, r)
             return
         }
-        code := parts[0]
+        code := store.resolveCode(parts[0])
 
         // GET /api/lobbies/{code}/ws
         if len(parts) == 2 && parts[1] == "ws" && r.Method == http.MethodGet {
@@ -107,31 +103,109 @@ func main() {
         if len(parts) == 1 && r.Method == http.MethodGet {
             session, ok := store.GetSession(code)
             if !ok {
-                http.Error(w, "session not found", http.StatusNotFound)
+                localizedError(w, r, store, code, errSessionNotFound, http.StatusNotFound)
                 return
             }
             writeJSON(w, http.StatusOK, session)
             return
         }
 
+        // GET /api/lobbies/{code}/leaderboard?metric=given|drank|perfect
+        if len(parts) == 2 && parts[1] == "leaderboard" && r.Method == http.MethodGet {
+            session, ok := store.GetSession(code)
+            if !ok {
+                localizedError(w, r, store, code, errSessionNotFound, http.StatusNotFound)
+                return
+            }
+            metric := r.URL.Query().Get("metric")
+            if metric == "" {
+                metric = MetricGiven
+            }
+            entries, err := SessionLeaderboard(session, metric)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            writeJSON(w, http.StatusOK, entries)
+            return
+        }
+
+        // GET /api/lobbies/{code}/history?game=N&format=json|ndjson
+        if len(parts) == 2 && parts[1] == "history" && r.Method == http.MethodGet {
+            game, _ := strconv.Atoi(r.URL.Query().Get("game"))
+            entries, err := store.History(code, game)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusInternalServerError)
+                return
+            }
+            if len(entries) == 0 {
+                localizedError(w, r, store, code, errHistoryNotFound, http.StatusNotFound)
+                return
+            }
+
+            if r.URL.Query().Get("format") == "ndjson" {
+                w.Header().Set("Content-Type", "application/x-ndjson")
+                w.Header().Set("Content-Disposition", `attachment; filename="`+normalizeCode(code)+`.ndjson"`)
+                w.WriteHeader(http.StatusOK)
+                enc := json.NewEncoder(w)
+                for _, entry := range entries {
+                    _ = enc.Encode(entry)
+                }
+                return
+            }
+            unit := LobbySettings{}.Penalty.unit()
+            if session, ok := store.GetSession(code); ok {
+                unit = session.Settings.Penalty.unit()
+            }
+            writeJSON(w, http.StatusOK, map[string]any{
+                "code":    normalizeCode(code),
+                "unit":    unit,
+                "entries": entries,
+            })
+            return
+        }
+
+        // GET /api/lobbies/{code}/events
+        if len(parts) == 2 && parts[1] == "events" && r.Method == http.MethodGet {
+            events, err := store.Events(code)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusInternalServerError)
+                return
+            }
+            writeJSON(w, http.StatusOK, events)
+            return
+        }
+
+        // GET /api/lobbies/{code}/ledger?playerId=...
+        if len(parts) == 2 && parts[1] == "ledger" && r.Method == http.MethodGet {
+            session, ok := store.GetSession(code)
+            if !ok {
+                localizedError(w, r, store, code, errSessionNotFound, http.StatusNotFound)
+                return
+            }
+            if pID := r.URL.Query().Get("playerId"); pID != "" {
+                writeJSON(w, http.StatusOK, LedgerFor(session, pID))
+                return
+            }
+            writeJSON(w, http.StatusOK, session.Ledger)
+            return
+        }
+
         // POST /api/lobbies/{code}/join
         if len(parts) == 2 && parts[1] == "join" && r.Method == http.MethodPost {
             var body struct {
-                Name string `json:"name"`
+                Name         string `json:"name"`
+                ProfileToken string `json:"profileToken"`
             }
             _ = json.NewDecoder(r.Body).Decode(&body)
 
-            player, session, err := store.JoinSession(code, body.Name)
+            player, session, err := store.JoinSession(code, body.Name, body.ProfileToken)
             if err != nil {
-                http.Error(w, err.Error(), http.StatusBadRequest)
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
                 return
             }
 
-            if bus != nil {
-                _ = bus.PublishSession(ctx, session)
-            } else {
-                hub.broadcastSession(session.Code, session)
-            }
+            publishSession(ctx, session, bus, hub)
 
             writeJSON(w, http.StatusOK, map[string]any{
                 "playerId": player.ID,
@@ -140,18 +214,34 @@ func main() {
             return
         }
 
+        // POST /api/lobbies/{code}/join-link
+        if len(parts) == 2 && parts[1] == "join-link" && r.Method == http.MethodPost {
+            var body struct {
+                HostID string `json:"hostId"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+
+            token, expires, err := store.JoinLink(code, body.HostID)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            writeJSON(w, http.StatusOK, map[string]any{
+                "token":     token,
+                "path":      "/play/" + token,
+                "expiresAt": expires,
+            })
+            return
+        }
+
         // POST /api/lobbies/{code}/close
         if len(parts) == 2 && parts[1] == "close" && r.Method == http.MethodPost {
             session, err := store.CloseSession(code, 30*time.Second)
             if err != nil {
-                http.Error(w, err.Error(), http.StatusBadRequest)
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
                 return
             }
-            if bus != nil {
-                _ = bus.PublishSession(ctx, session)
-            } else {
-                hub.broadcastSession(session.Code, session)
-            }
+            publishSession(ctx, session, bus, hub)
             writeJSON(w, http.StatusOK, session)
             return
         }
@@ -160,19 +250,11 @@ func main() {
         if len(parts) == 2 && parts[1] == "start" && r.Method == http.MethodPost {
             session, err := store.StartSession(code)
             if err != nil {
-                http.Error(w, err.Error(), http.StatusBadRequest)
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
                 return
             }
-            if bus != nil {
-                _ = bus.PublishSession(ctx, session)
-            } else {
-                hub.broadcastSession(session.Code, session)
-            }
-            if session.Game.Started {
-                scheduleAutoAdvance(ctx, code, session.Game.Round, store, bus, hub)
-            } else if session.Game.DistributionActive && session.Game.DistributionDeadline != nil {
-                scheduleDistributionFinalize(ctx, code, *session.Game.DistributionDeadline, store, bus, hub)
-            }
+            publishSession(ctx, session, bus, hub)
+            scheduleTimers(ctx, code, session, store, bus, hub)
             writeJSON(w, http.StatusOK, session)
             return
         }
@@ -201,29 +283,17 @@ func main() {
 
             session, err := store.SubmitGuess(code, pID, body.Choice)
             if err != nil {
-                http.Error(w, err.Error(), http.StatusBadRequest)
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
                 return
             }
 
-            if bus != nil {
-                _ = bus.PublishSession(ctx, session)
-            } else {
-                hub.broadcastSession(session.Code, session)
-            }
+            publishSession(ctx, session, bus, hub)
 
             // Auto-advance immediately if everyone has guessed
             if allGuessed(session) {
                 if nextSession, err := store.AdvanceRound(code); err == nil {
-                    if bus != nil {
-                        _ = bus.PublishSession(ctx, nextSession)
-                    } else {
-                        hub.broadcastSession(nextSession.Code, nextSession)
-                    }
-                    if nextSession.Game.Started {
-                        scheduleAutoAdvance(ctx, code, nextSession.Game.Round, store, bus, hub)
-                    } else if nextSession.Game.DistributionActive && nextSession.Game.DistributionDeadline != nil {
-                        scheduleDistributionFinalize(ctx, code, *nextSession.Game.DistributionDeadline, store, bus, hub)
-                    }
+                    publishSession(ctx, nextSession, bus, hub)
+                    scheduleTimers(ctx, code, nextSession, store, bus, hub)
                 }
             }
 
@@ -235,19 +305,239 @@ func main() {
         if len(parts) == 2 && parts[1] == "next" && r.Method == http.MethodPost {
             session, err := store.AdvanceRound(code)
             if err != nil {
-                http.Error(w, err.Error(), http.StatusBadRequest)
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
                 return
             }
-            if bus != nil {
-                _ = bus.PublishSession(ctx, session)
+            publishSession(ctx, session, bus, hub)
+            scheduleTimers(ctx, code, session, store, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/undo
+        if len(parts) == 2 && parts[1] == "undo" && r.Method == http.MethodPost {
+            var body struct {
+                HostID string `json:"hostId"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+
+            session, err := store.Undo(code, body.HostID)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            scheduleTimers(ctx, code, session, store, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/pause, POST /api/lobbies/{code}/resume
+        if len(parts) == 2 && (parts[1] == "pause" || parts[1] == "resume") && r.Method == http.MethodPost {
+            var body struct {
+                HostID string `json:"hostId"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+
+            var session *Session
+            var err error
+            if parts[1] == "pause" {
+                session, err = store.Pause(code, body.HostID)
             } else {
-                hub.broadcastSession(session.Code, session)
+                session, err = store.Resume(code, body.HostID)
+            }
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            scheduleTimers(ctx, code, session, store, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/settings
+        if len(parts) == 2 && parts[1] == "settings" && r.Method == http.MethodPost {
+            var body struct {
+                HostID   string          `json:"hostId"`
+                Settings json.RawMessage `json:"settings"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+
+            session, err := store.UpdateSettings(code, body.HostID, body.Settings)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/ride
+        if len(parts) == 2 && parts[1] == "ride" && r.Method == http.MethodPost {
+            var body struct {
+                PlayerID string `json:"playerId"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+            if body.PlayerID == "" {
+                localizedError(w, r, store, code, errPlayerIDRequired, http.StatusBadRequest)
+                return
+            }
+
+            session, err := store.LetItRide(code, body.PlayerID)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/bets
+        if len(parts) == 2 && parts[1] == "bets" && r.Method == http.MethodPost {
+            var body struct {
+                PlayerID  string `json:"playerId"`
+                TargetID  string `json:"targetId"`
+                OnCorrect bool   `json:"onCorrect"`
+                Amount    int    `json:"amount"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+            if body.PlayerID == "" {
+                localizedError(w, r, store, code, errPlayerIDRequired, http.StatusBadRequest)
+                return
+            }
+
+            session, err := store.PlaceSideBet(code, SideBet{
+                BettorID:  body.PlayerID,
+                TargetID:  body.TargetID,
+                OnCorrect: body.OnCorrect,
+                Amount:    body.Amount,
+            })
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/bots
+        if len(parts) == 2 && parts[1] == "bots" && r.Method == http.MethodPost {
+            var body struct {
+                HostID   string `json:"hostId"`
+                Strategy string `json:"strategy"`
+                Count    int    `json:"count"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+            if body.Strategy == "" {
+                body.Strategy = BotRandom
+            }
+            if body.Count <= 0 {
+                body.Count = 1
+            }
+
+            var session *Session
+            for i := 0; i < body.Count; i++ {
+                var err error
+                session, err = store.AddBot(code, body.HostID, body.Strategy)
+                if err != nil {
+                    localizedError(w, r, store, code, err, http.StatusBadRequest)
+                    return
+                }
+            }
+            publishSession(ctx, session, bus, hub)
+            // Adding bots leaves the phase and its deadline alone, so only the
+            // bots need arming; the phase timer is already running.
+            scheduleBots(ctx, code, session, store, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/teams
+        if len(parts) == 2 && parts[1] == "teams" && r.Method == http.MethodPost {
+            var body struct {
+                HostID string `json:"hostId"`
+                Name   string `json:"name"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+
+            session, err := store.CreateTeam(code, body.HostID, body.Name)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/teams/assign (teamId "" removes the player from their team)
+        if len(parts) == 3 && parts[1] == "teams" && parts[2] == "assign" && r.Method == http.MethodPost {
+            var body struct {
+                HostID   string `json:"hostId"`
+                PlayerID string `json:"playerId"`
+                TeamID   string `json:"teamId"`
+                Captain  bool   `json:"captain"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+            if body.PlayerID == "" {
+                localizedError(w, r, store, code, errPlayerIDRequired, http.StatusBadRequest)
+                return
+            }
+
+            session, err := store.AssignTeam(code, body.HostID, body.PlayerID, body.TeamID, body.Captain)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/ack
+        if len(parts) == 2 && parts[1] == "ack" && r.Method == http.MethodPost {
+            var body struct {
+                PlayerID string   `json:"playerId"`
+                IDs      []string `json:"ids"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+            if body.PlayerID == "" {
+                localizedError(w, r, store, code, errPlayerIDRequired, http.StatusBadRequest)
+                return
+            }
+
+            session, err := store.AckDrinks(code, body.PlayerID, body.IDs)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        // POST /api/lobbies/{code}/drink-profile
+        if len(parts) == 2 && parts[1] == "drink-profile" && r.Method == http.MethodPost {
+            var body struct {
+                PlayerID string `json:"playerId"`
+                DrinkProfile
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+            if body.PlayerID == "" {
+                localizedError(w, r, store, code, errPlayerIDRequired, http.StatusBadRequest)
+                return
             }
-            if session.Game.Started {
-                scheduleAutoAdvance(ctx, code, session.Game.Round, store, bus, hub)
-            } else if session.Game.DistributionActive && session.Game.DistributionDeadline != nil {
-                scheduleDistributionFinalize(ctx, code, *session.Game.DistributionDeadline, store, bus, hub)
+
+            session, err := store.SetDrinkProfile(code, body.PlayerID, body.DrinkProfile)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
             }
+            publishSession(ctx, session, bus, hub)
             writeJSON(w, http.StatusOK, session)
             return
         }
@@ -273,20 +563,25 @@ func main() {
                 }
             }
             if pID == "" {
-                http.Error(w, "playerId required", http.StatusBadRequest)
+                localizedError(w, r, store, code, errPlayerIDRequired, http.StatusBadRequest)
                 return
             }
 
+            inWindow := false
+            if before, ok := store.GetSession(code); ok {
+                inWindow = before.Game.Started && before.Game.DistributionActive
+            }
+
             session, err := store.DistributeDrinks(code, pID, body.Allocations)
             if err != nil {
-                http.Error(w, err.Error(), http.StatusBadRequest)
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
                 return
             }
 
-            if bus != nil {
-                _ = bus.PublishSession(ctx, session)
-            } else {
-                hub.broadcastSession(session.Code, session)
+            publishSession(ctx, session, bus, hub)
+            // Closing a between-rounds window starts the next round.
+            if inWindow && !session.Game.DistributionActive {
+                scheduleTimers(ctx, code, session, store, bus, hub)
             }
 
             writeJSON(w, http.StatusOK, session)
@@ -313,35 +608,23 @@ func main() {
                 }
             }
             if pID == "" {
-                http.Error(w, "playerId required", http.StatusBadRequest)
+                localizedError(w, r, store, code, errPlayerIDRequired, http.StatusBadRequest)
                 return
             }
 
             session, err := store.TapOut(code, pID)
             if err != nil {
-                http.Error(w, err.Error(), http.StatusBadRequest)
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
                 return
             }
 
-            if bus != nil {
-                _ = bus.PublishSession(ctx, session)
-            } else {
-                hub.broadcastSession(session.Code, session)
-            }
+            publishSession(ctx, session, bus, hub)
 
             // if everyone else already guessed, advance now
             if allGuessed(session) {
                 if nextSession, err := store.AdvanceRound(code); err == nil {
-                    if bus != nil {
-                        _ = bus.PublishSession(ctx, nextSession)
-                    } else {
-                        hub.broadcastSession(nextSession.Code, nextSession)
-                    }
-                    if nextSession.Game.Started {
-                        scheduleAutoAdvance(ctx, code, nextSession.Game.Round, store, bus, hub)
-                    } else if nextSession.Game.DistributionActive && nextSession.Game.DistributionDeadline != nil {
-                        scheduleDistributionFinalize(ctx, code, *nextSession.Game.DistributionDeadline, store, bus, hub)
-                    }
+                    publishSession(ctx, nextSession, bus, hub)
+                    scheduleTimers(ctx, code, nextSession, store, bus, hub)
                 }
             }
 
@@ -349,6 +632,251 @@ func main() {
             return
         }
 
+        // POST /api/lobbies/{code}/tap/cancel
+        if len(parts) == 3 && parts[1] == "tap" && parts[2] == "cancel" && r.Method == http.MethodPost {
+            var body struct {
+                PlayerID string `json:"playerId"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+            if body.PlayerID == "" {
+                localizedError(w, r, store, code, errPlayerIDRequired, http.StatusBadRequest)
+                return
+            }
+
+            session, err := store.CancelTapOut(code, body.PlayerID)
+            if err != nil {
+                localizedError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            publishSession(ctx, session, bus, hub)
+            writeJSON(w, http.StatusOK, session)
+            return
+        }
+
+        http.NotFound(w, r)
+    })
+
+    // GET /api/leaderboards/{window}/{metric}?period=2026-W42&limit=10
+    mux.HandleFunc("/api/leaderboards/", func(w http.ResponseWriter, r *http.Request) {
+        allowCORS(w)
+        if r.Method == http.MethodOptions {
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+        if r.Method != http.MethodGet {
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        path := strings.TrimPrefix(r.URL.Path, "/api/leaderboards/")
+        parts := strings.Split(strings.Trim(path, "/"), "/")
+        if len(parts) != 2 {
+            http.NotFound(w, r)
+            return
+        }
+
+        limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+        entries, err := store.Leaderboard(parts[0], r.URL.Query().Get("period"), parts[1], limit)
+        if err != nil {
+            writeLocalizedError(w, r, requestLocale(r), err, http.StatusBadRequest)
+            return
+        }
+        writeJSON(w, http.StatusOK, entries)
+    })
+
+    // POST /api/profiles, GET /api/profiles?name=...
+    mux.HandleFunc("/api/profiles", func(w http.ResponseWriter, r *http.Request) {
+        allowCORS(w)
+        if r.Method == http.MethodOptions {
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+
+        switch r.Method {
+        case http.MethodPost:
+            var body struct {
+                Name  string `json:"name"`
+                Claim bool   `json:"claim"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+
+            profile, token, err := store.CreateProfile(body.Name, body.Claim)
+            if err != nil {
+                writeLocalizedError(w, r, requestLocale(r), err, http.StatusBadRequest)
+                return
+            }
+            // The device token is only ever returned here.
+            writeJSON(w, http.StatusCreated, struct {
+                ProfileStats
+                Token string `json:"token"`
+            }{profile.Stats(), token})
+        case http.MethodGet:
+            profile, ok := store.GetProfileByName(r.URL.Query().Get("name"))
+            if !ok {
+                writeLocalizedError(w, r, requestLocale(r), errProfileNotFound, http.StatusNotFound)
+                return
+            }
+            writeJSON(w, http.StatusOK, profile.Stats())
+        default:
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        }
+    })
+
+    // GET /api/profiles/{id}
+    mux.HandleFunc("/api/profiles/", func(w http.ResponseWriter, r *http.Request) {
+        allowCORS(w)
+        if r.Method == http.MethodOptions {
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+        if r.Method != http.MethodGet {
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/profiles/"), "/")
+        profile, ok := store.GetProfile(id)
+        if !ok {
+            writeLocalizedError(w, r, requestLocale(r), errProfileNotFound, http.StatusNotFound)
+            return
+        }
+        writeJSON(w, http.StatusOK, profile.Stats())
+    })
+
+    // POST /api/tournaments
+    mux.HandleFunc("/api/tournaments", func(w http.ResponseWriter, r *http.Request) {
+        allowCORS(w)
+        if r.Method == http.MethodOptions {
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+        if r.Method != http.MethodPost {
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        var body struct {
+            HostName        string `json:"hostName"`
+            Name            string `json:"name"`
+            Tables          int    `json:"tables"`
+            AdvancePerTable int    `json:"advancePerTable"`
+        }
+        _ = json.NewDecoder(r.Body).Decode(&body)
+
+        t, err := store.CreateTournament(body.HostName, body.Name, requestLocale(r), body.Tables, body.AdvancePerTable)
+        if err != nil {
+            tournamentError(w, r, store, "", err, http.StatusBadRequest)
+            return
+        }
+        writeJSON(w, http.StatusCreated, map[string]any{
+            "hostId":     t.HostID,
+            "tournament": t,
+        })
+    })
+
+    mux.HandleFunc("/api/tournaments/", func(w http.ResponseWriter, r *http.Request) {
+        allowCORS(w)
+        if r.Method == http.MethodOptions {
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+
+        path := strings.TrimPrefix(r.URL.Path, "/api/tournaments/")
+        parts := strings.Split(strings.Trim(path, "/"), "/")
+        if len(parts) == 0 || parts[0] == "" {
+            http.NotFound(w, r)
+            return
+        }
+        code := normalizeCode(parts[0])
+
+        // GET /api/tournaments/{code}/ws
+        if len(parts) == 2 && parts[1] == "ws" && r.Method == http.MethodGet {
+            serveTournamentWS(w, r, store, hub, code)
+            return
+        }
+
+        // GET /api/tournaments/{code}
+        if len(parts) == 1 && r.Method == http.MethodGet {
+            t, ok := store.GetTournament(code)
+            if !ok {
+                tournamentError(w, r, store, code, errTournamentNotFound, http.StatusNotFound)
+                return
+            }
+            writeJSON(w, http.StatusOK, map[string]any{
+                "tournament": t,
+                "standings":  store.Standings(t),
+            })
+            return
+        }
+
+        // GET /api/tournaments/{code}/standings
+        if len(parts) == 2 && parts[1] == "standings" && r.Method == http.MethodGet {
+            t, ok := store.GetTournament(code)
+            if !ok {
+                tournamentError(w, r, store, code, errTournamentNotFound, http.StatusNotFound)
+                return
+            }
+            writeJSON(w, http.StatusOK, store.Standings(t))
+            return
+        }
+
+        // POST /api/tournaments/{code}/join
+        if len(parts) == 2 && parts[1] == "join" && r.Method == http.MethodPost {
+            var body struct {
+                Name         string `json:"name"`
+                ProfileToken string `json:"profileToken"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+
+            t, entrant, err := store.JoinTournament(code, body.Name, body.ProfileToken)
+            if err != nil {
+                tournamentError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            if session, ok := store.GetSession(entrant.Table); ok {
+                publishSession(ctx, session, bus, hub)
+            }
+            writeJSON(w, http.StatusOK, map[string]any{
+                "playerId":   entrant.ID,
+                "code":       entrant.Table,
+                "tournament": t,
+            })
+            return
+        }
+
+        // POST /api/tournaments/{code}/final
+        if len(parts) == 2 && parts[1] == "final" && r.Method == http.MethodPost {
+            var body struct {
+                HostID string `json:"hostId"`
+            }
+            _ = json.NewDecoder(r.Body).Decode(&body)
+
+            t, err := store.StartFinal(code, body.HostID)
+            if err != nil {
+                tournamentError(w, r, store, code, err, http.StatusBadRequest)
+                return
+            }
+            if session, ok := store.GetSession(t.FinalTable); ok {
+                publishSession(ctx, session, bus, hub)
+            }
+            // Finalists switch tables: tell each client its new player ID.
+            seats := map[string]string{}
+            for _, e := range t.Entrants {
+                if e.FinalID != "" {
+                    seats[e.ID] = e.FinalID
+                }
+            }
+            publishTournament(ctx, t.Code, EventTournamentFinal, map[string]any{
+                "code":  t.FinalTable,
+                "seats": seats,
+            }, bus, hub)
+            writeJSON(w, http.StatusOK, map[string]any{
+                "tournament": t,
+                "standings":  store.Standings(t),
+            })
+            return
+        }
+
         http.NotFound(w, r)
     })
 
@@ -389,6 +917,12 @@ func allGuessed(s *Session) bool {
     }
     actual := 0
     for _, pid := range s.Game.ActivePlayers {
+        if team := teamOf(s, pid); team != nil {
+            if teamDecided(s, team) {
+                actual++
+            }
+            continue
+        }
         guesses := s.Game.Guesses[pid]
         if len(guesses) > s.Game.Round && guesses[s.Game.Round] != "" {
             actual++
@@ -397,26 +931,34 @@ func allGuessed(s *Session) bool {
     return actual >= expected
 }
 
-func scheduleAutoAdvance(ctx context.Context, code string, round int, store *RedisStore, bus *redisBus, hub *lobbyHub) {
-    time.AfterFunc(RoundDuration, func() {
+// scheduleTimers arms the timer matching the session's current phase.
+func scheduleTimers(ctx context.Context, code string, session *Session, store *RedisStore, bus *redisBus, hub *lobbyHub) {
+    scheduleBots(ctx, code, session, store, bus, hub)
+    if session.Game.Started && session.Game.Deadline != nil {
+        scheduleAutoAdvance(ctx, code, *session.Game.Deadline, store, bus, hub)
+    } else if session.Game.DistributionActive && session.Game.DistributionDeadline != nil {
+        scheduleDistributionFinalize(ctx, code, *session.Game.DistributionDeadline, store, bus, hub)
+    }
+}
+
+func scheduleAutoAdvance(ctx context.Context, code string, expectedDeadline time.Time, store *RedisStore, bus *redisBus, hub *lobbyHub) {
+    wait := time.Until(expectedDeadline)
+    if wait < 0 {
+        wait = 0
+    }
+    time.AfterFunc(wait, func() {
         session, ok := store.GetSession(code)
         // If game ended or round already advanced, do nothing
-        if !ok || !session.Game.Started || session.Game.Round != round {
+        if !ok || !session.Game.Started || session.Game.Deadline == nil {
             return
         }
+        if !session.Game.Deadline.Equal(expectedDeadline) {
+            return // stale timer
+        }
 
         if nextSession, err := store.AdvanceRound(code); err == nil {
-            if bus != nil {
-                _ = bus.PublishSession(ctx, nextSession)
-            } else {
-                hub.broadcastSession(nextSession.Code, nextSession)
-            }
-
-            if nextSession.Game.Started {
-                scheduleAutoAdvance(ctx, code, nextSession.Game.Round, store, bus, hub)
-            } else if nextSession.Game.DistributionActive && nextSession.Game.DistributionDeadline != nil {
-                scheduleDistributionFinalize(ctx, code, *nextSession.Game.DistributionDeadline, store, bus, hub)
-            }
+            publishSession(ctx, nextSession, bus, hub)
+            scheduleTimers(ctx, code, nextSession, store, bus, hub)
         }
     })
 }
@@ -439,12 +981,30 @@ func scheduleDistributionFinalize(ctx context.Context, code string, expectedDead
         if err != nil {
             return
         }
+        publishSession(ctx, nextSession, bus, hub)
+        scheduleTimers(ctx, code, nextSession, store, bus, hub)
+    })
+}
+
+// publishSession fans a session update out to every WS client, through Redis
+// when available, followed by any lobby events the mutation produced.
+func publishSession(ctx context.Context, session *Session, bus *redisBus, hub *lobbyHub) {
+    if bus != nil {
+        _ = bus.PublishSession(ctx, session)
+    } else {
+        hub.broadcastSession(session.Code, session)
+    }
+    for _, ev := range session.drainEvents() {
         if bus != nil {
-            _ = bus.PublishSession(ctx, nextSession)
+            _ = bus.PublishEvent(ctx, ev)
         } else {
-            hub.broadcastSession(nextSession.Code, nextSession)
+            hub.broadcastEvent(ev)
         }
-    })
+    }
+    // Tournament screens refetch standings when one of their tables changes.
+    if session.TournamentID != "" {
+        publishTournament(ctx, session.TournamentID, EventTournamentTable, map[string]any{"code": session.Code}, bus, hub)
+    }
 }
 
 func allowCORS(w http.ResponseWriter) {
@@ -455,6 +1015,7 @@ func allowCORS(w http.ResponseWriter) {
     w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
     w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
     w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+    w.Header().Set("Access-Control-Expose-Headers", "X-Error-Code")
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
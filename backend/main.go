@@ -15,9 +15,27 @@ const maxCodeGenerationAttempts = 256
 type Player struct {
     ID           string `json:"id"`
     Name         string `json:"name"`
+    // Score, LifetimeDrank and GivenOut are derived from Session.Ledger.
     Score        int    `json:"score"` // kept for backward compatibility
     LifetimeDrank int   `json:"lifetimeDrank"`
     GivenOut     int    `json:"givenOut"`
+    ProfileID    string   `json:"profileId,omitempty"`
+    Badges       []string `json:"badges,omitempty"`
+    SuitStreak   int      `json:"suitStreak,omitempty"`
+    // RoundsSurvived counts correct guesses in this lobby; tournaments rank
+    // survivors by it.
+    RoundsSurvived int    `json:"roundsSurvived,omitempty"`
+    // PerfectRuns counts games in this lobby where the player survived all
+    // four rounds; LastPerfectGame is the latest of them.
+    PerfectRuns     int   `json:"perfectRuns,omitempty"`
+    LastPerfectGame int   `json:"lastPerfectGame,omitempty"`
+
+    DrinkProfile *DrinkProfile `json:"drinkProfile,omitempty"`
+    BACWarned    bool          `json:"bacWarned,omitempty"`
+
+    // Bot players are driven by the server using BotStrategy.
+    Bot          bool   `json:"bot,omitempty"`
+    BotStrategy  string `json:"botStrategy,omitempty"`
 }
 
 type Session struct {
@@ -28,6 +46,53 @@ type Session struct {
     Game           GameState  `json:"game"`
     Status         string     `json:"status"` // active | closing
     ShuttingDownAt *time.Time `json:"shuttingDownAt,omitempty"`
+    GamesPlayed    int        `json:"gamesPlayed"`
+    Version        string     `json:"version,omitempty"` // ID of the last applied stream event
+    Undo           *UndoPoint `json:"undo,omitempty"`
+
+    Settings       LobbySettings  `json:"settings"`
+    Ledger         []LedgerEntry  `json:"ledger"`
+    DrinkSeq       int            `json:"drinkSeq"`
+    Teams          []Team         `json:"teams,omitempty"`
+    TournamentID   string         `json:"tournamentId,omitempty"`
+    PIN            string         `json:"pin,omitempty"`
+    Ended          bool           `json:"ended,omitempty"` // served from the code registry's tombstone
+
+    // events collects lobby events produced by the current mutation; they are
+    // published right after the session itself and never persisted.
+    events []lobbyEvent
+    // history holds log entries not yet appended to the lobby's history.
+    history []HistoryEntry
+    // clock pins "now" while an event is applied, so replays are exact.
+    clock time.Time
+    // sinceSnapshot counts events folded on top of the stored snapshot.
+    sinceSnapshot int
+}
+
+func (s *Session) now() time.Time {
+    if !s.clock.IsZero() {
+        return s.clock
+    }
+    return time.Now().UTC()
+}
+
+// lobbyEvent is a one-off notification for WS clients, sent alongside (not
+// instead of) the regular session update.
+type lobbyEvent struct {
+    Type string      `json:"type"`
+    Code string      `json:"code"`
+    Unit PenaltyUnit `json:"unit"`
+    Data any         `json:"data"`
+}
+
+func (s *Session) emit(eventType string, data any) {
+    s.events = append(s.events, lobbyEvent{Type: eventType, Code: s.Code, Unit: s.Settings.Penalty.unit(), Data: data})
+}
+
+func (s *Session) drainEvents() []lobbyEvent {
+    events := s.events
+    s.events = nil
+    return events
 }
 
 type Store struct {
@@ -39,17 +104,25 @@ func newStore() *Store {
     return &Store{sessions: map[string]*Session{}}
 }
 
+// normalizeCode turns a word code or join link into the lobby's word code.
+// PINs need a lookup and are resolved by RedisStore.resolveCode first.
 func normalizeCode(code string) string {
-    return strings.ToUpper(strings.TrimSpace(code))
+    code = strings.TrimSpace(code)
+    if strings.HasPrefix(code, joinTokenPrefix) {
+        if resolved, _, err := parseJoinToken(code, time.Now()); err == nil {
+            return resolved
+        }
+    }
+    return strings.ToUpper(code)
 }
 
-func (s *Store) CreateSession(hostName string) (*Session, Player, error) {
+func (s *Store) CreateSession(hostName, locale string) (*Session, Player, error) {
     s.mu.Lock()
     defer s.mu.Unlock()
 
     var code string
     for i := 0; i < maxCodeGenerationAttempts; i++ {
-        candidate, err := generateLobbyCode()
+        candidate, err := generateLobbyCode(locale)
         if err != nil {
             return nil, Player{}, err
         }
@@ -75,6 +148,7 @@ func (s *Store) CreateSession(hostName string) (*Session, Player, error) {
         Players:   []Player{host},
         CreatedAt: time.Now().UTC(),
         Game:      GameState{},
+        Settings:  LobbySettings{Locale: locale},
     }
     s.sessions[code] = session
     return session, host, nil
@@ -86,12 +160,12 @@ func (s *Store) JoinSession(code, name string) (Player, *Session, error) {
 
     session, ok := s.sessions[normalizeCode(code)]
     if !ok {
-        return Player{}, nil, errors.New("session not found")
+        return Player{}, nil, errSessionNotFound
     }
 
     name = strings.TrimSpace(name)
     if name == "" {
-        return Player{}, nil, errors.New("name required")
+        return Player{}, nil, errNameRequired
     }
 
     player := Player{
@@ -117,7 +191,7 @@ func (s *Store) StartSession(code string) (*Session, error) {
 
     session, ok := s.sessions[normalizeCode(code)]
     if !ok {
-        return nil, errors.New("session not found")
+        return nil, errSessionNotFound
     }
 
     if err := StartGame(session); err != nil {
@@ -148,32 +222,39 @@ var verbs = []string{
     "celebrates", "mingles",
 }
 
-func generateLobbyCode() (string, error) {
-    a, err := pickWord(adjectives)
-    if err != nil {
-        return "", err
-    }
-    b, err := pickWord(animals)
-    if err != nil {
-        return "", err
-    }
-    c, err := pickWord(verbs)
-    if err != nil {
-        return "", err
+func generateLobbyCode(locale string) (string, error) {
+    wl := wordlistFor(locale)
+    for i := 0; i < maxCodeGenerationAttempts; i++ {
+        a, err := pickWord(wl.Adjectives)
+        if err != nil {
+            return "", err
+        }
+        b, err := pickWord(wl.Animals)
+        if err != nil {
+            return "", err
+        }
+        c, err := pickWord(wl.Verbs)
+        if err != nil {
+            return "", err
+        }
+        if acceptableCode(a, b, c) {
+            return strings.ToUpper(a + "-" + b + "-" + c), nil
+        }
     }
-    return strings.ToUpper(a + "-" + b + "-" + c), nil
+    return "", errors.New("unable to generate lobby code")
 }
 
-func generateRandomHostName() (string, error) {
-    a, err := pickWord(adjectives)
+func generateRandomHostName(locale string) (string, error) {
+    wl := wordlistFor(locale)
+    a, err := pickWord(wl.Adjectives)
     if err != nil {
         return "", err
     }
-    b, err := pickWord(animals)
+    b, err := pickWord(wl.Animals)
     if err != nil {
         return "", err
     }
-    return strings.Title(a) + strings.Title(b), nil
+    return capitalize(a) + capitalize(b), nil
 }
 
 func pickWord(words []string) (string, error) {
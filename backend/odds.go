@@ -0,0 +1,62 @@
+package main
+
+// classicOptions lists the guesses of a classic round in display order.
+func classicOptions(round int) []string {
+    switch round {
+    case 0:
+        return []string{"red", "black"}
+    case 1:
+        return []string{"higher", "lower"}
+    case 2:
+        return []string{"between", "outside"}
+    case 3:
+        return []string{"hearts", "diamonds", "clubs", "spades"}
+    default:
+        return nil
+    }
+}
+
+// refreshOdds recomputes the odds shown to players for the current round.
+func refreshOdds(s *Session) {
+    s.Game.Odds = nil
+    if s.Settings.TrainingWheels && s.Game.Started {
+        s.Game.Odds = guessOdds(s.Settings.Rounds, s.Game.Shared, s.Game.Round)
+    }
+}
+
+// guessOdds returns the exact chance of each option winning the round,
+// given only the cards revealed before it (shared[:round]). The unknown card
+// is drawn uniformly from the rest of the deck.
+func guessOdds(rules []RoundRule, shared [4]Card, round int) map[string]float64 {
+    options := roundOptions(rules, round)
+    if options == nil {
+        return nil
+    }
+
+    known := map[Card]bool{}
+    for _, c := range shared[:round] {
+        known[c] = true
+    }
+
+    wins := map[string]int{}
+    total := 0
+    for _, c := range newDeck() {
+        if known[c] {
+            continue
+        }
+        total++
+        hand := shared
+        hand[round] = c
+        for _, opt := range options {
+            if correctGuess(rules, hand, round, opt) {
+                wins[opt]++
+            }
+        }
+    }
+
+    odds := make(map[string]float64, len(options))
+    for _, opt := range options {
+        odds[opt] = float64(wins[opt]) / float64(total)
+    }
+    return odds
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestGuessOddsFromRemainingDeck(t *testing.T) {
+    shared := [4]Card{{3, Hearts}, {10, Hearts}, {5, Spades}, {2, Clubs}}
+    near := func(got, want float64) bool { return got-want < 1e-9 && want-got < 1e-9 }
+
+    if odds := guessOdds(nil, shared, 0); !near(odds["red"], 0.5) {
+        t.Errorf("expected even red/black odds, got %v", odds)
+    }
+    // 44 of the remaining 51 cards beat a 3; the other three 3s lose both ways.
+    if odds := guessOdds(nil, shared, 1); !near(odds["higher"], 44.0/51) || !near(odds["lower"], 4.0/51) {
+        t.Errorf("unexpected higher/lower odds %v", odds)
+    }
+    // Ranks 4..9 lie strictly between 3 and 10: 24 of the remaining 50 cards.
+    if odds := guessOdds(nil, shared, 2); !near(odds["between"], 24.0/50) || !near(odds["outside"], 20.0/50) {
+        t.Errorf("unexpected between/outside odds %v", odds)
+    }
+    // Two hearts and a spade are out of the deck.
+    if odds := guessOdds(nil, shared, 3); !near(odds["hearts"], 11.0/49) || !near(odds["clubs"], 13.0/49) {
+        t.Errorf("unexpected suit odds %v", odds)
+    }
+}
+
+func TestTrainingWheelsPublishOdds(t *testing.T) {
+    s := newTestSession("a")
+    if s.Game.Odds != nil {
+        t.Fatal("expected no odds without training wheels")
+    }
+    if err := UpdateSettings(s, "host", LobbySettings{TrainingWheels: true}); err != nil {
+        t.Fatal(err)
+    }
+    if s.Game.Odds["red"] != 0.5 {
+        t.Errorf("expected red odds of 0.5, got %v", s.Game.Odds)
+    }
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+    UnitSips    = "sips"
+    UnitPushUps = "push-ups"
+    UnitPoints  = "points"
+    UnitCustom  = "custom"
+
+    EventChallengeDrawn = "challenge_drawn"
+)
+
+const maxChallengeCards = 200
+
+// PenaltySettings decides what the stake numbers count. The default is the
+// classic drinking game; other units make it work for office and family
+// nights. An optional deck hands each loser a challenge card.
+type PenaltySettings struct {
+    Unit     string   `json:"unit"`
+    Singular string   `json:"singular,omitempty"` // custom unit only
+    Plural   string   `json:"plural,omitempty"`   // custom unit only
+    Deck     []string `json:"deck,omitempty"`
+}
+
+// PenaltyUnit is the display metadata attached to sessions and events.
+type PenaltyUnit struct {
+    Kind     string `json:"kind"`
+    Singular string `json:"singular"`
+    Plural   string `json:"plural"`
+    Alcohol  bool   `json:"alcohol"`
+}
+
+var builtinUnits = map[string]PenaltyUnit{
+    UnitSips:    {Kind: UnitSips, Singular: "sip", Plural: "sips", Alcohol: true},
+    UnitPushUps: {Kind: UnitPushUps, Singular: "push-up", Plural: "push-ups"},
+    UnitPoints:  {Kind: UnitPoints, Singular: "point", Plural: "points"},
+}
+
+func (ps PenaltySettings) validate() error {
+    switch ps.Unit {
+    case "", UnitSips, UnitPushUps, UnitPoints:
+    case UnitCustom:
+        if strings.TrimSpace(ps.Plural) == "" {
+            return errors.New("custom unit needs a label")
+        }
+    default:
+        return errors.New("unknown penalty unit")
+    }
+    if len(ps.Deck) > maxChallengeCards {
+        return errors.New("challenge deck is too large")
+    }
+    for _, card := range ps.Deck {
+        if strings.TrimSpace(card) == "" {
+            return errors.New("challenge cards must not be empty")
+        }
+    }
+    return nil
+}
+
+func (ps PenaltySettings) unit() PenaltyUnit {
+    if ps.Unit == UnitCustom {
+        singular := strings.TrimSpace(ps.Singular)
+        plural := strings.TrimSpace(ps.Plural)
+        if singular == "" {
+            singular = plural
+        }
+        return PenaltyUnit{Kind: UnitCustom, Singular: singular, Plural: plural}
+    }
+    if u, ok := builtinUnits[ps.Unit]; ok {
+        return u
+    }
+    return builtinUnits[UnitSips]
+}
+
+// MarshalJSON adds the session's display unit, derived from its settings
+// rather than stored next to them.
+func (s Session) MarshalJSON() ([]byte, error) {
+    type plain Session
+    return json.Marshal(struct {
+        plain
+        Unit PenaltyUnit `json:"unit"`
+    }{plain(s), s.Settings.Penalty.unit()})
+}
+
+// shuffledDeck returns a crypto-shuffled copy of the lobby's challenge deck.
+func shuffledDeck(deck []string) ([]string, error) {
+    out := append([]string(nil), deck...)
+    for i := len(out) - 1; i > 0; i-- {
+        j, err := cryptoInt(i + 1)
+        if err != nil {
+            return nil, err
+        }
+        out[i], out[j] = out[j], out[i]
+    }
+    return out, nil
+}
+
+// drawChallenge hands out the next card of the game's shuffled deck, cycling
+// when it runs out. It returns "" when the lobby has no deck.
+func drawChallenge(s *Session) string {
+    deck := s.Game.ChallengeDeck
+    if len(deck) == 0 {
+        return ""
+    }
+    card := deck[s.Game.ChallengesDrawn%len(deck)]
+    s.Game.ChallengesDrawn++
+    return card
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLosersDrawChallengeCards(t *testing.T) {
+    s := newTestSession("a")
+    s.Settings.Penalty = PenaltySettings{Unit: UnitPushUps, Deck: []string{"Sing a song"}}
+    s.Game.ChallengeDeck = []string{"Sing a song"}
+
+    if err := SubmitGuess(s, "a", "black"); err != nil {
+        t.Fatal(err)
+    }
+    if err := AdvanceRound(s); err != nil {
+        t.Fatal(err)
+    }
+
+    if got := s.Game.Results[0].Challenge; got != "Sing a song" {
+        t.Errorf("expected loser to draw the challenge, got %q", got)
+    }
+    events := s.drainEvents()
+    if len(events) == 0 || events[0].Unit.Kind != UnitPushUps {
+        t.Errorf("expected events to carry the push-up unit, got %+v", events)
+    }
+}
+
+func TestSessionUnitFollowsSettings(t *testing.T) {
+    s := newTestSession("a")
+    s.Settings.Penalty = PenaltySettings{Unit: UnitCustom, Singular: "lap", Plural: "laps"}
+
+    b, err := json.Marshal(s)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var out struct {
+        Code string      `json:"code"`
+        Unit PenaltyUnit `json:"unit"`
+    }
+    if err := json.Unmarshal(b, &out); err != nil {
+        t.Fatal(err)
+    }
+    if out.Code != "TEST" || out.Unit.Plural != "laps" {
+        t.Errorf("expected the unit derived from settings, got %+v", out)
+    }
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Profile is a long-lived player identity that outlives sessions. Its ID is
+// public (it shows up in sessions and leaderboards); playing as the profile
+// takes the secret device token handed out once at creation. A profile can
+// optionally claim its name so its stats can be looked up by name.
+type Profile struct {
+    ID             string         `json:"id"`
+    Name           string         `json:"name"`
+    Claimed        bool           `json:"claimed"`
+    GamesPlayed    int            `json:"gamesPlayed"`
+    RoundsPlayed   [4]int         `json:"roundsPlayed"`
+    RoundsSurvived [4]int         `json:"roundsSurvived"`
+    GuessCounts    map[string]int `json:"guessCounts"`
+    TotalDrank     int            `json:"totalDrank"`
+    TotalGiven     int            `json:"totalGiven"`
+    PerfectRuns    int            `json:"perfectRuns"`
+    CreatedAt      time.Time      `json:"createdAt"`
+    UpdatedAt      time.Time      `json:"updatedAt"`
+}
+
+// ProfileStats is the read model returned by the API.
+type ProfileStats struct {
+    Profile
+    SurvivalRate  [4]float64 `json:"survivalRate"`
+    FavoriteGuess string     `json:"favoriteGuess,omitempty"`
+}
+
+func profileKey(id string) string { return "profile:" + strings.TrimSpace(id) }
+
+func profileTokenKey(token string) string { return "profile_token:" + strings.TrimSpace(token) }
+
+func profileNameKey(name string) string {
+    return "profile_name:" + strings.ToLower(strings.TrimSpace(name))
+}
+
+func (p Profile) Stats() ProfileStats {
+    out := ProfileStats{Profile: p}
+    for i := range p.RoundsPlayed {
+        if p.RoundsPlayed[i] > 0 {
+            out.SurvivalRate[i] = float64(p.RoundsSurvived[i]) / float64(p.RoundsPlayed[i])
+        }
+    }
+    best := 0
+    for guess, n := range p.GuessCounts {
+        if n > best || (n == best && guess < out.FavoriteGuess) {
+            best = n
+            out.FavoriteGuess = guess
+        }
+    }
+    return out
+}
+
+// profileGame is what one finished game adds to a profile and its
+// leaderboards. It is kept with the once-per-game marker so an undo of the
+// game's end can take exactly this back out.
+type profileGame struct {
+    ProfileID      string         `json:"profileId"`
+    Period         string         `json:"period"`
+    RoundsPlayed   [4]int         `json:"roundsPlayed"`
+    RoundsSurvived [4]int         `json:"roundsSurvived"`
+    Guesses        map[string]int `json:"guesses"`
+    Metrics        map[string]int `json:"metrics"`
+}
+
+func newProfileGame(s *Session, player Player, now time.Time) profileGame {
+    g := profileGame{
+        ProfileID: player.ProfileID,
+        Period:    weekLabel(now),
+        Guesses:   map[string]int{},
+        Metrics:   playerMetrics(s, player, s.GamesPlayed),
+    }
+    for _, r := range s.Game.Results {
+        if r.PlayerID != player.ID || r.Round < 0 || r.Round > 3 {
+            continue
+        }
+        g.RoundsPlayed[r.Round]++
+        if r.Correct {
+            g.RoundsSurvived[r.Round]++
+        }
+        if r.Guess != "" {
+            g.Guesses[r.Guess]++
+        }
+    }
+    return g
+}
+
+// applyGame folds one finished game into the profile; sign -1 takes it back
+// out.
+func (p *Profile) applyGame(g profileGame, sign int) {
+    if p.GuessCounts == nil {
+        p.GuessCounts = map[string]int{}
+    }
+    p.GamesPlayed += sign
+    for i := range g.RoundsPlayed {
+        p.RoundsPlayed[i] += sign * g.RoundsPlayed[i]
+        p.RoundsSurvived[i] += sign * g.RoundsSurvived[i]
+    }
+    for guess, n := range g.Guesses {
+        p.GuessCounts[guess] += sign * n
+        if p.GuessCounts[guess] <= 0 {
+            delete(p.GuessCounts, guess)
+        }
+    }
+    p.TotalDrank += sign * g.Metrics[MetricDrank]
+    p.TotalGiven += sign * g.Metrics[MetricGiven]
+    p.PerfectRuns += sign * g.Metrics[MetricPerfect]
+    p.UpdatedAt = time.Now().UTC()
+}
+
+// CreateProfile stores a new profile and returns it with its device token,
+// which is never shown again.
+func (s *RedisStore) CreateProfile(name string, claim bool) (*Profile, string, error) {
+    name = strings.TrimSpace(name)
+    if name == "" {
+        return nil, "", errNameRequired
+    }
+    token, err := newUUIDv4()
+    if err != nil {
+        return nil, "", err
+    }
+
+    now := time.Now().UTC()
+    profile := &Profile{
+        ID:          newID("profile_"),
+        Name:        name,
+        Claimed:     claim,
+        GuessCounts: map[string]int{},
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+
+    if claim {
+        ok, err := s.rdb.SetNX(s.ctx, profileNameKey(name), profile.ID, 0).Result()
+        if err != nil {
+            return nil, "", err
+        }
+        if !ok {
+            return nil, "", errNameClaimed
+        }
+    }
+
+    err = s.saveProfile(profile)
+    if err == nil {
+        err = s.rdb.Set(s.ctx, profileTokenKey(token), profile.ID, 0).Err()
+    }
+    if err != nil {
+        // Release the name so a retry can claim it.
+        if claim {
+            _ = s.rdb.Del(s.ctx, profileNameKey(name)).Err()
+        }
+        _ = s.rdb.Del(s.ctx, profileKey(profile.ID)).Err()
+        return nil, "", err
+    }
+    return profile, token, nil
+}
+
+func (s *RedisStore) GetProfile(id string) (*Profile, bool) {
+    if strings.TrimSpace(id) == "" {
+        return nil, false
+    }
+    raw, err := s.rdb.Get(s.ctx, profileKey(id)).Result()
+    if err != nil {
+        return nil, false
+    }
+    var profile Profile
+    if err := json.Unmarshal([]byte(raw), &profile); err != nil {
+        return nil, false
+    }
+    return &profile, true
+}
+
+// ProfileByToken finds the profile a device token unlocks.
+func (s *RedisStore) ProfileByToken(token string) (*Profile, bool) {
+    if strings.TrimSpace(token) == "" {
+        return nil, false
+    }
+    id, err := s.rdb.Get(s.ctx, profileTokenKey(token)).Result()
+    if err != nil {
+        return nil, false
+    }
+    return s.GetProfile(id)
+}
+
+func (s *RedisStore) GetProfileByName(name string) (*Profile, bool) {
+    id, err := s.rdb.Get(s.ctx, profileNameKey(name)).Result()
+    if err != nil {
+        return nil, false
+    }
+    return s.GetProfile(id)
+}
+
+func (s *RedisStore) saveProfile(profile *Profile) error {
+    b, _ := json.Marshal(profile)
+    // Profiles are persistent: no TTL, unlike sessions.
+    return s.rdb.Set(s.ctx, profileKey(profile.ID), b, 0).Err()
+}
+
+// profileStatsKey is the once-per-game marker. It names the lobby instance,
+// not just the code, since codes are reused after their cool-down.
+func profileStatsKey(session *Session) string {
+    return "profile_stats:" + normalizeCode(session.Code) + ":" +
+        strconv.FormatInt(session.CreatedAt.UnixNano(), 10) + ":" + strconv.Itoa(session.GamesPlayed)
+}
+
+// recordProfileStats updates the profiles of every linked player once the
+// game has finished. A marker key, holding what was added, makes it run once
+// per game.
+func (s *RedisStore) recordProfileStats(session *Session) error {
+    if !gameFinished(session) {
+        return nil
+    }
+    now := time.Now().UTC()
+    var games []profileGame
+    for _, p := range session.Players {
+        if p.ProfileID == "" || p.ID == session.HostID {
+            continue
+        }
+        games = append(games, newProfileGame(session, p, now))
+    }
+    b, _ := json.Marshal(games)
+    first, err := s.rdb.SetNX(s.ctx, profileStatsKey(session), b, historyTTL).Result()
+    if err != nil || !first {
+        return err
+    }
+    return s.applyProfileGames(games, 1)
+}
+
+// revertProfileStats takes a game back out of profiles and leaderboards
+// after an undo reopened it, and clears the marker so the corrected result
+// is recorded when the game ends again.
+func (s *RedisStore) revertProfileStats(session *Session) error {
+    if gameFinished(session) {
+        return nil
+    }
+    key := profileStatsKey(session)
+    raw, err := s.rdb.Get(s.ctx, key).Result()
+    if err == redis.Nil {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    // Only the caller that removes the marker reverts.
+    if n, err := s.rdb.Del(s.ctx, key).Result(); err != nil || n == 0 {
+        return err
+    }
+    var games []profileGame
+    if err := json.Unmarshal([]byte(raw), &games); err != nil {
+        return err
+    }
+    return s.applyProfileGames(games, -1)
+}
+
+func (s *RedisStore) applyProfileGames(games []profileGame, sign int) error {
+    for _, g := range games {
+        profile, ok := s.GetProfile(g.ProfileID)
+        if !ok {
+            continue
+        }
+        profile.applyGame(g, sign)
+        if err := s.saveProfile(profile); err != nil {
+            return err
+        }
+        if err := s.bumpLeaderboards(g, sign); err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfileTokenIsTheOnlyWayToPlayAsAProfile(t *testing.T) {
+    store, _ := newTestStore(t)
+
+    profile, token, err := store.CreateProfile("Ada", true)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if token == "" || token == profile.ID {
+        t.Fatalf("expected a device token separate from the ID, got %q", token)
+    }
+    if _, _, err := store.CreateProfile("ada", true); err == nil {
+        t.Error("expected a claimed name to be taken")
+    }
+
+    found, ok := store.GetProfileByName("ADA")
+    if !ok || found.ID != profile.ID {
+        t.Fatalf("expected name lookup to find the profile, got %+v", found)
+    }
+
+    session, _, err := store.CreateSession("Host", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, _, err := store.JoinSession(session.Code, "Mallory", found.ID); err == nil {
+        t.Error("expected the public profile ID to be refused as a credential")
+    }
+    player, _, err := store.JoinSession(session.Code, "Ada", token)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if player.ProfileID != profile.ID {
+        t.Errorf("expected player linked to %s, got %q", profile.ID, player.ProfileID)
+    }
+}
+
+func TestFailedProfileSaveReleasesName(t *testing.T) {
+    store, fr := newTestStore(t)
+
+    fr.failSetsOn("profile:")
+    if _, _, err := store.CreateProfile("Ada", true); err == nil {
+        t.Fatal("expected the injected save failure")
+    }
+    fr.failSetsOn("")
+    if _, _, err := store.CreateProfile("Ada", true); err != nil {
+        t.Fatalf("expected the name to be free again: %v", err)
+    }
+}
+
+func TestProfileStatsSurvivalAndFavorite(t *testing.T) {
+    p := Profile{
+        RoundsPlayed:   [4]int{4, 2, 0, 0},
+        RoundsSurvived: [4]int{3, 1, 0, 0},
+        GuessCounts:    map[string]int{"red": 3, "black": 3, "higher": 1},
+    }
+    stats := p.Stats()
+    if stats.SurvivalRate[0] != 0.75 || stats.SurvivalRate[1] != 0.5 || stats.SurvivalRate[2] != 0 {
+        t.Errorf("unexpected survival rates %v", stats.SurvivalRate)
+    }
+    if stats.FavoriteGuess != "black" {
+        t.Errorf("expected ties to break alphabetically, got %q", stats.FavoriteGuess)
+    }
+}
+
+func TestRecycledCodeStillRecordsProfileStats(t *testing.T) {
+    store, fr := newTestStore(t)
+    locale := singleCodeLocale(t)
+    profile, token, err := store.CreateProfile("Ada", false)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    playOneGame := func() string {
+        t.Helper()
+        session, _, err := store.CreateSession("Host", locale)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if _, _, err := store.JoinSession(session.Code, "Ada", token); err != nil {
+            t.Fatal(err)
+        }
+        if _, err := store.StartSession(session.Code); err != nil {
+            t.Fatal(err)
+        }
+        // Nobody guessed, so the only player is out and the game ends.
+        if _, err := store.AdvanceRound(session.Code); err != nil {
+            t.Fatal(err)
+        }
+        return session.Code
+    }
+
+    first := playOneGame()
+    fr.advance(store.ttl + CodeCooldown + time.Minute)
+    if second := playOneGame(); second != first {
+        t.Fatalf("expected the code to be reused, got %s then %s", first, second)
+    }
+    if p, _ := store.GetProfile(profile.ID); p.GamesPlayed != 2 {
+        t.Errorf("expected both lobbies' first game to count, got %d games", p.GamesPlayed)
+    }
+}
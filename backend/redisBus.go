@@ -30,6 +30,23 @@ func lobbyChannel(code string) string {
     return "lobby:" + normalizeCode(code)
 }
 
+func lobbyEventChannel(code string) string {
+    return "lobbyevent:" + normalizeCode(code)
+}
+
+func (b *redisBus) PublishEvent(ctx context.Context, ev lobbyEvent) error {
+    if b == nil || b.rdb == nil {
+        return errors.New("redis bus not initialized")
+    }
+
+    payload, err := json.Marshal(ev)
+    if err != nil {
+        return err
+    }
+
+    return b.rdb.Publish(ctx, lobbyEventChannel(ev.Code), payload).Err()
+}
+
 func (b *redisBus) PublishSession(ctx context.Context, session *Session) error {
     if b == nil || b.rdb == nil || session == nil {
         return errors.New("redis bus not initialized")
@@ -43,12 +60,20 @@ func (b *redisBus) PublishSession(ctx context.Context, session *Session) error {
     return b.rdb.Publish(ctx, lobbyChannel(session.Code), payload).Err()
 }
 
+// PublishTournament sends a tournament-level message to its WS clients.
+func (b *redisBus) PublishTournament(ctx context.Context, code string, payload []byte) error {
+    if b == nil || b.rdb == nil {
+        return errors.New("redis bus not initialized")
+    }
+    return b.rdb.Publish(ctx, tournamentChannel(code), payload).Err()
+}
+
 func (b *redisBus) SubscribeAndBroadcast(ctx context.Context, hub *lobbyHub) {
     if b == nil || b.rdb == nil {
         return
     }
 
-    pubsub := b.rdb.PSubscribe(ctx, "lobby:*")
+    pubsub := b.rdb.PSubscribe(ctx, "lobby:*", "lobbyevent:*", "tournament:*")
     ch := pubsub.Channel()
 
     go func() {
@@ -61,6 +86,17 @@ func (b *redisBus) SubscribeAndBroadcast(ctx context.Context, hub *lobbyHub) {
                 if !ok {
                     return
                 }
+                if msg.Pattern == "tournament:*" {
+                    hub.broadcastRaw(msg.Channel, []byte(msg.Payload))
+                    continue
+                }
+                if msg.Pattern == "lobbyevent:*" {
+                    var ev lobbyEvent
+                    if err := json.Unmarshal([]byte(msg.Payload), &ev); err == nil {
+                        hub.broadcastEvent(ev)
+                    }
+                    continue
+                }
                 var session Session
                 if err := json.Unmarshal([]byte(msg.Payload), &session); err == nil {
                     hub.broadcastSession(session.Code, &session)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
 	"os"
 	"strings"
 	"time"
@@ -12,8 +13,8 @@ import (
 )
 
 type sessionStore interface {
-    CreateSession(hostName string) (*Session, Player, error)
-    JoinSession(code, name string) (Player, *Session, error)
+    CreateSession(hostName, locale string) (*Session, Player, error)
+    JoinSession(code, name, profileToken string) (Player, *Session, error)
     GetSession(code string) (*Session, bool)
     CloseSession(code string, grace time.Duration) (*Session, error)
     StartSession(code string) (*Session, error)
@@ -41,44 +42,78 @@ func newRedisStore(ctx context.Context) (*RedisStore, error) {
 
 func sessionKey(code string) string { return "session:" + normalizeCode(code) }
 
-func (s *RedisStore) CreateSession(hostName string) (*Session, Player, error) {
+func (s *RedisStore) CreateSession(hostName, locale string) (*Session, Player, error) {
     hostName = strings.TrimSpace(hostName)
     if hostName == "" {
-        if rnd, err := generateRandomHostName(); err == nil && rnd != "" {
+        if rnd, err := generateRandomHostName(locale); err == nil && rnd != "" {
             hostName = rnd
         } else {
             hostName = "Host"
         }
     }
     host := Player{ID: newID("host_"), Name: hostName}
+    session, err := s.createSession(host, "", locale)
+    return session, host, err
+}
 
+// createSession reserves a fresh lobby code for the given host. Tournament
+// tables share the tournament host and remember which tournament they are in.
+func (s *RedisStore) createSession(host Player, tournamentCode, locale string) (*Session, error) {
     for i := 0; i < maxCodeGenerationAttempts; i++ {
-        code, err := generateLobbyCode()
+        code, err := generateLobbyCode(locale)
         if err != nil {
-            return nil, Player{}, err
+            return nil, err
         }
         session := &Session{
-            HostID:    host.ID,
-            Code:      code,
-            Players:   []Player{host},
-            CreatedAt: time.Now().UTC(),
-            Game:      GameState{},
-            Status:    "active",
+            HostID:       host.ID,
+            Code:         code,
+            Players:      []Player{host},
+            CreatedAt:    time.Now().UTC(),
+            Game:         GameState{},
+            Status:       "active",
+            TournamentID: tournamentCode,
+            Settings:     LobbySettings{Locale: locale},
+        }
+        ok, err := s.reserveCode(session)
+        if err != nil {
+            return nil, err
         }
+        if !ok {
+            continue
+        }
+        // Lobbies created before the registry existed only hold their
+        // session key.
         b, _ := json.Marshal(session)
-        ok, err := s.rdb.SetNX(s.ctx, sessionKey(code), b, s.ttl).Result()
+        ok, err = s.rdb.SetNX(s.ctx, sessionKey(code), b, s.ttl).Result()
         if err != nil {
-            return nil, Player{}, err
+            return nil, err
         }
         if ok {
-            return session, host, nil
+            // Drop any stream left over from an expired lobby with this code,
+            // and its history, which outlives the code's cool-down.
+            _ = s.rdb.Del(s.ctx, eventsKey(code), historyKey(code)).Err()
+            if pin, err := s.reservePIN(code); err != nil {
+                log.Printf("lobby %s: reserving PIN: %v", code, err)
+            } else {
+                session.PIN = pin
+            }
+            if err := s.writeSnapshot(session, s.ttl); err != nil {
+                return nil, err
+            }
+            return session, nil
         }
     }
-    return nil, Player{}, errors.New("unable to generate unique lobby code")
+    return nil, errors.New("unable to generate unique lobby code")
 }
 
+// GetSession loads the latest snapshot and folds the newer stream events on
+// top of it. Events that no longer apply (e.g. a guess that raced a round
+// advance) are skipped, exactly as they were rejected when first applied.
 func (s *RedisStore) GetSession(code string) (*Session, bool) {
     raw, err := s.rdb.Get(s.ctx, sessionKey(code)).Result()
+    if err == redis.Nil {
+        return s.endedSession(code)
+    }
     if err != nil {
         return nil, false
     }
@@ -86,27 +121,79 @@ func (s *RedisStore) GetSession(code string) (*Session, bool) {
     if err := json.Unmarshal([]byte(raw), &session); err != nil {
         return nil, false
     }
+
+    events, err := s.readEvents(code, session.Version)
+    if err != nil {
+        return nil, false
+    }
+    for _, ev := range events {
+        _ = applyEvent(&session, ev)
+        session.Version = ev.ID
+        session.sinceSnapshot++
+    }
+    // Side effects of replayed events were already published and logged.
+    session.drainEvents()
+    session.history = nil
     return &session, true
 }
 
-func (s *RedisStore) JoinSession(code, name string) (Player, *Session, error) {
+// JoinSession seats a new player, linked to the profile whose device token
+// is given, if any.
+func (s *RedisStore) JoinSession(code, name, profileToken string) (Player, *Session, error) {
+    profileID, err := s.profileForToken(profileToken)
+    if err != nil {
+        return Player{}, nil, err
+    }
+    return s.joinSession(code, name, profileID)
+}
+
+// profileForToken resolves an optional device token to its profile ID.
+func (s *RedisStore) profileForToken(token string) (string, error) {
+    if strings.TrimSpace(token) == "" {
+        return "", nil
+    }
+    profile, ok := s.ProfileByToken(token)
+    if !ok {
+        return "", errProfileNotFound
+    }
+    return profile.ID, nil
+}
+
+// joinSession seats a player linked to an already verified profile ID.
+func (s *RedisStore) joinSession(code, name, profileID string) (Player, *Session, error) {
+    token := strings.TrimSpace(code)
+    if !strings.HasPrefix(token, joinTokenPrefix) {
+        token = ""
+    } else if _, _, err := parseJoinToken(token, time.Now()); err != nil {
+        return Player{}, nil, err
+    }
     session, ok := s.GetSession(code)
     if !ok {
-        return Player{}, nil, errors.New("session not found")
+        return Player{}, nil, errSessionNotFound
+    }
+    if session.Ended {
+        return Player{}, nil, errLobbyEnded
     }
     if session.Status != "active" {
-        return Player{}, nil, errors.New("session is closing")
+        return Player{}, nil, errSessionClosing
     }
     name = strings.TrimSpace(name)
     if name == "" {
-        return Player{}, nil, errors.New("name required")
+        return Player{}, nil, errNameRequired
     }
 
     player := Player{ID: newID("player_"), Name: name}
-    session.Players = append(session.Players, player)
+    player.ProfileID = profileID
+    // A join link is spent only once the join is otherwise certain to work.
+    if token != "" {
+        if _, err := s.consumeJoinToken(token); err != nil {
+            return Player{}, nil, err
+        }
+    }
 
-    b, _ := json.Marshal(session)
-    if err := s.rdb.Set(s.ctx, sessionKey(code), b, s.ttl).Err(); err != nil {
+    ev := newSessionEvent(SessionEventPlayerJoined)
+    ev.Player = &player
+    if err := s.apply(session, ev); err != nil {
         return Player{}, nil, err
     }
     return player, session, nil
@@ -115,14 +202,18 @@ func (s *RedisStore) JoinSession(code, name string) (Player, *Session, error) {
 func (s *RedisStore) CloseSession(code string, grace time.Duration) (*Session, error) {
     session, ok := s.GetSession(code)
     if !ok {
-        return nil, errors.New("session not found")
+        return nil, errSessionNotFound
     }
-    t := time.Now().UTC().Add(grace)
-    session.Status = "closing"
-    session.ShuttingDownAt = &t
 
-    b, _ := json.Marshal(session)
-    if err := s.rdb.Set(s.ctx, sessionKey(code), b, grace).Err(); err != nil {
+    ev := newSessionEvent(SessionEventClosed)
+    ev.Grace = grace
+    if err := applyEvent(session, ev); err != nil {
+        return nil, err
+    }
+    if err := s.commit(session, ev, grace); err != nil {
+        return nil, err
+    }
+    if err := s.endCode(session, grace); err != nil {
         return nil, err
     }
     return session, nil
@@ -131,13 +222,21 @@ func (s *RedisStore) CloseSession(code string, grace time.Duration) (*Session, e
 func (s *RedisStore) StartSession(code string) (*Session, error) {
     session, ok := s.GetSession(code)
     if !ok {
-        return nil, errors.New("session not found")
+        return nil, errSessionNotFound
     }
-    if err := StartGame(session); err != nil {
+    cards, err := drawUniqueCards(4)
+    if err != nil {
         return nil, err
     }
-    b, _ := json.Marshal(session)
-    if err := s.rdb.Set(s.ctx, sessionKey(code), b, s.ttl).Err(); err != nil {
+    deck, err := shuffledDeck(session.Settings.Penalty.Deck)
+    if err != nil {
+        return nil, err
+    }
+
+    ev := newSessionEvent(SessionEventGameStarted)
+    ev.Cards = cards
+    ev.Challenges = deck
+    if err := s.apply(session, ev); err != nil {
         return nil, err
     }
     return session, nil
@@ -146,74 +245,267 @@ func (s *RedisStore) StartSession(code string) (*Session, error) {
 func (s *RedisStore) SubmitGuess(code, playerID, guess string) (*Session, error) {
     session, ok := s.GetSession(code)
     if !ok {
-        return nil, errors.New("session not found")
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventGuessSubmitted)
+    ev.PlayerID = playerID
+    ev.Guess = guess
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
+
+func (s *RedisStore) AdvanceRound(code string) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
     }
-    if err := SubmitGuess(session, playerID, guess); err != nil {
+    if err := s.apply(session, newSessionEvent(SessionEventRoundAdvanced)); err != nil {
         return nil, err
     }
-    b, _ := json.Marshal(session)
-    if err := s.rdb.Set(s.ctx, sessionKey(code), b, s.ttl).Err(); err != nil {
+    return session, nil
+}
+
+func (s *RedisStore) DistributeDrinks(code, fromPlayerID string, allocations map[string]int) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventDrinksDistributed)
+    ev.PlayerID = fromPlayerID
+    ev.Allocations = allocations
+    if err := s.apply(session, ev); err != nil {
         return nil, err
     }
     return session, nil
 }
 
-func (s *RedisStore) AdvanceRound(code string) (*Session, error) {
+func (s *RedisStore) FinalizeDistribution(code string) (*Session, error) {
     session, ok := s.GetSession(code)
     if !ok {
-        return nil, errors.New("session not found")
+        return nil, errSessionNotFound
     }
-    if err := AdvanceRound(session); err != nil {
+    if !session.Game.DistributionActive {
+        return session, nil
+    }
+    assignments, err := planFinalizeDistribution(session)
+    if err != nil {
         return nil, err
     }
-    b, _ := json.Marshal(session)
-    if err := s.rdb.Set(s.ctx, sessionKey(code), b, s.ttl).Err(); err != nil {
+
+    ev := newSessionEvent(SessionEventDistributionFinalized)
+    ev.Assignments = assignments
+    if err := s.apply(session, ev); err != nil {
         return nil, err
     }
     return session, nil
 }
 
-func (s *RedisStore) DistributeDrinks(code, fromPlayerID string, allocations map[string]int) (*Session, error) {
+func (s *RedisStore) TapOut(code, playerID string) (*Session, error) {
     session, ok := s.GetSession(code)
     if !ok {
-        return nil, errors.New("session not found")
+        return nil, errSessionNotFound
     }
-    if err := DistributeDrinks(session, fromPlayerID, allocations); err != nil {
+
+    ev := newSessionEvent(SessionEventTapOutRequested)
+    ev.PlayerID = playerID
+    if err := s.apply(session, ev); err != nil {
         return nil, err
     }
-    b, _ := json.Marshal(session)
-    if err := s.rdb.Set(s.ctx, sessionKey(code), b, s.ttl).Err(); err != nil {
+    return session, nil
+}
+
+func (s *RedisStore) CancelTapOut(code, playerID string) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventTapOutCancelled)
+    ev.PlayerID = playerID
+    if err := s.apply(session, ev); err != nil {
         return nil, err
     }
     return session, nil
 }
 
-func (s *RedisStore) FinalizeDistribution(code string) (*Session, error) {
+func (s *RedisStore) Undo(code, requesterID string) (*Session, error) {
     session, ok := s.GetSession(code)
     if !ok {
-        return nil, errors.New("session not found")
+        return nil, errSessionNotFound
     }
-    if err := FinalizeDistribution(session); err != nil {
+
+    ev := newSessionEvent(SessionEventUndone)
+    ev.PlayerID = requesterID
+    if err := s.apply(session, ev); err != nil {
         return nil, err
     }
-    b, _ := json.Marshal(session)
-    if err := s.rdb.Set(s.ctx, sessionKey(code), b, s.ttl).Err(); err != nil {
+    return session, nil
+}
+
+func (s *RedisStore) Pause(code, requesterID string) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventPaused)
+    ev.PlayerID = requesterID
+    if err := s.apply(session, ev); err != nil {
         return nil, err
     }
     return session, nil
 }
 
-func (s *RedisStore) TapOut(code, playerID string) (*Session, error) {
+func (s *RedisStore) Resume(code, requesterID string) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventResumed)
+    ev.PlayerID = requesterID
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
+
+// UpdateSettings overlays the JSON patch on the current settings, so the host
+// only has to send the fields it changes.
+func (s *RedisStore) UpdateSettings(code, requesterID string, patch json.RawMessage) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    settings := session.Settings
+    if len(patch) > 0 {
+        if err := json.Unmarshal(patch, &settings); err != nil {
+            return nil, errors.New("invalid settings")
+        }
+    }
+
+    ev := newSessionEvent(SessionEventSettingsUpdated)
+    ev.PlayerID = requesterID
+    ev.Settings = &settings
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
+
+func (s *RedisStore) AckDrinks(code, playerID string, ids []string) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventDrinksAcked)
+    ev.PlayerID = playerID
+    ev.IDs = ids
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
+
+func (s *RedisStore) SetDrinkProfile(code, playerID string, dp DrinkProfile) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventDrinkProfileSet)
+    ev.PlayerID = playerID
+    ev.Drink = &dp
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
+
+func (s *RedisStore) CreateTeam(code, requesterID, name string) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventTeamCreated)
+    ev.PlayerID = requesterID
+    ev.Team = &Team{ID: newID("team_"), Name: name}
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
+
+func (s *RedisStore) AssignTeam(code, requesterID, playerID, teamID string, captain bool) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventTeamAssigned)
+    ev.PlayerID = requesterID
+    ev.TargetID = playerID
+    if teamID != "" {
+        ev.Team = &Team{ID: teamID}
+    }
+    ev.Captain = captain
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
+
+func (s *RedisStore) AddBot(code, requesterID, strategy string) (*Session, error) {
     session, ok := s.GetSession(code)
     if !ok {
-        return nil, errors.New("session not found")
+        return nil, errSessionNotFound
     }
-    if err := TapOut(session, playerID); err != nil {
+    name, err := botName(strategy, session.Settings.Locale)
+    if err != nil {
         return nil, err
     }
-    b, _ := json.Marshal(session)
-    if err := s.rdb.Set(s.ctx, sessionKey(code), b, s.ttl).Err(); err != nil {
+
+    ev := newSessionEvent(SessionEventBotAdded)
+    ev.PlayerID = requesterID
+    ev.Player = &Player{ID: newID("bot_"), Name: name, Bot: true, BotStrategy: strategy}
+    if err := s.apply(session, ev); err != nil {
         return nil, err
     }
     return session, nil
-}
\ No newline at end of file
+}
+
+func (s *RedisStore) LetItRide(code, playerID string) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventLetItRide)
+    ev.PlayerID = playerID
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
+
+func (s *RedisStore) PlaceSideBet(code string, bet SideBet) (*Session, error) {
+    session, ok := s.GetSession(code)
+    if !ok {
+        return nil, errSessionNotFound
+    }
+
+    ev := newSessionEvent(SessionEventSideBetPlaced)
+    ev.PlayerID = bet.BettorID
+    ev.Bet = &bet
+    if err := s.apply(session, ev); err != nil {
+        return nil, err
+    }
+    return session, nil
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// fakeRedis is a tiny in-memory RESP2 server covering the commands the
+// store uses, so store code can be tested without a real Redis. Expiry runs
+// on a manual clock moved with advance.
+type fakeRedis struct {
+    mu      sync.Mutex
+    now     time.Time
+    strings map[string]string
+    lists   map[string][]string
+    streams map[string][][2]string
+    zsets   map[string]map[string]float64
+    expires map[string]time.Time
+    seq     int
+
+    // failSet makes SET on keys with this prefix fail.
+    failSet string
+}
+
+// newTestStore returns a RedisStore backed by a fresh fakeRedis.
+func newTestStore(t *testing.T) (*RedisStore, *fakeRedis) {
+    t.Helper()
+    fr := &fakeRedis{
+        now:     time.Date(2026, 1, 1, 20, 0, 0, 0, time.UTC),
+        strings: map[string]string{},
+        lists:   map[string][]string{},
+        streams: map[string][][2]string{},
+        zsets:   map[string]map[string]float64{},
+        expires: map[string]time.Time{},
+    }
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    go func() {
+        for {
+            conn, err := ln.Accept()
+            if err != nil {
+                return
+            }
+            go fr.serve(conn)
+        }
+    }()
+    rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIndentity: true})
+    t.Cleanup(func() {
+        _ = rdb.Close()
+        _ = ln.Close()
+    })
+    return &RedisStore{ctx: context.Background(), rdb: rdb, ttl: 2 * time.Hour}, fr
+}
+
+func (fr *fakeRedis) failSetsOn(prefix string) {
+    fr.mu.Lock()
+    defer fr.mu.Unlock()
+    fr.failSet = prefix
+}
+
+// advance moves the fake clock, expiring keys whose TTL ran out.
+func (fr *fakeRedis) advance(d time.Duration) {
+    fr.mu.Lock()
+    defer fr.mu.Unlock()
+    fr.now = fr.now.Add(d)
+}
+
+func (fr *fakeRedis) exists(key string) bool {
+    _, s := fr.strings[key]
+    _, l := fr.lists[key]
+    _, x := fr.streams[key]
+    _, z := fr.zsets[key]
+    return s || l || x || z
+}
+
+func (fr *fakeRedis) expire(key string) {
+    if at, ok := fr.expires[key]; ok && !fr.now.Before(at) {
+        fr.del(key)
+    }
+}
+
+func (fr *fakeRedis) del(key string) bool {
+    found := fr.exists(key)
+    delete(fr.strings, key)
+    delete(fr.lists, key)
+    delete(fr.streams, key)
+    delete(fr.zsets, key)
+    delete(fr.expires, key)
+    return found
+}
+
+func (fr *fakeRedis) serve(conn net.Conn) {
+    defer conn.Close()
+    r := bufio.NewReader(conn)
+    var queued [][]string
+    inMulti := false
+    for {
+        args, err := readCommand(r)
+        if err != nil {
+            return
+        }
+        var out strings.Builder
+        switch name := strings.ToUpper(args[0]); {
+        case name == "MULTI":
+            inMulti = true
+            queued = nil
+            out.WriteString("+OK\r\n")
+        case name == "EXEC":
+            fmt.Fprintf(&out, "*%d\r\n", len(queued))
+            for _, cmd := range queued {
+                out.WriteString(fr.exec(cmd))
+            }
+            inMulti = false
+        case inMulti:
+            queued = append(queued, args)
+            out.WriteString("+QUEUED\r\n")
+        default:
+            out.WriteString(fr.exec(args))
+        }
+        if _, err := io.WriteString(conn, out.String()); err != nil {
+            return
+        }
+    }
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+    line, err := r.ReadString('\n')
+    if err != nil {
+        return nil, err
+    }
+    n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+    if err != nil || line[0] != '*' {
+        return nil, fmt.Errorf("bad request %q", line)
+    }
+    args := make([]string, n)
+    for i := range args {
+        head, err := r.ReadString('\n')
+        if err != nil {
+            return nil, err
+        }
+        size, _ := strconv.Atoi(strings.TrimSpace(head[1:]))
+        buf := make([]byte, size+2)
+        if _, err := io.ReadFull(r, buf); err != nil {
+            return nil, err
+        }
+        args[i] = string(buf[:size])
+    }
+    return args, nil
+}
+
+func bulk(s string) string { return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s) }
+func integer(n int) string { return fmt.Sprintf(":%d\r\n", n) }
+
+const nilBulk = "$-1\r\n"
+
+func (fr *fakeRedis) exec(args []string) string {
+    fr.mu.Lock()
+    defer fr.mu.Unlock()
+    if len(args) > 1 {
+        fr.expire(args[1])
+    }
+    switch strings.ToUpper(args[0]) {
+    case "PING":
+        return "+PONG\r\n"
+    case "PUBLISH":
+        return integer(0)
+    case "GET":
+        v, ok := fr.strings[args[1]]
+        if !ok {
+            return nilBulk
+        }
+        return bulk(v)
+    case "SET", "SETNX":
+        if fr.failSet != "" && strings.HasPrefix(args[1], fr.failSet) {
+            return "-ERR injected failure\r\n"
+        }
+        nx := strings.ToUpper(args[0]) == "SETNX"
+        var ttl time.Duration
+        for i := 3; i < len(args); i++ {
+            switch strings.ToUpper(args[i]) {
+            case "NX":
+                nx = true
+            case "EX":
+                n, _ := strconv.Atoi(args[i+1])
+                ttl = time.Duration(n) * time.Second
+                i++
+            case "PX":
+                n, _ := strconv.Atoi(args[i+1])
+                ttl = time.Duration(n) * time.Millisecond
+                i++
+            }
+        }
+        if nx && fr.exists(args[1]) {
+            if strings.ToUpper(args[0]) == "SETNX" {
+                return integer(0)
+            }
+            return nilBulk
+        }
+        fr.strings[args[1]] = args[2]
+        delete(fr.expires, args[1])
+        if ttl > 0 {
+            fr.expires[args[1]] = fr.now.Add(ttl)
+        }
+        if strings.ToUpper(args[0]) == "SETNX" {
+            return integer(1)
+        }
+        return "+OK\r\n"
+    case "DEL":
+        n := 0
+        for _, key := range args[1:] {
+            fr.expire(key)
+            if fr.del(key) {
+                n++
+            }
+        }
+        return integer(n)
+    case "EXPIRE", "PEXPIRE":
+        if !fr.exists(args[1]) {
+            return integer(0)
+        }
+        n, _ := strconv.Atoi(args[2])
+        unit := time.Second
+        if strings.ToUpper(args[0]) == "PEXPIRE" {
+            unit = time.Millisecond
+        }
+        fr.expires[args[1]] = fr.now.Add(time.Duration(n) * unit)
+        return integer(1)
+    case "RPUSH":
+        fr.lists[args[1]] = append(fr.lists[args[1]], args[2:]...)
+        return integer(len(fr.lists[args[1]]))
+    case "LRANGE":
+        list := fr.lists[args[1]]
+        var out strings.Builder
+        fmt.Fprintf(&out, "*%d\r\n", len(list))
+        for _, v := range list {
+            out.WriteString(bulk(v))
+        }
+        return out.String()
+    case "XADD":
+        fr.seq++
+        id := "1-" + strconv.Itoa(fr.seq)
+        fr.streams[args[1]] = append(fr.streams[args[1]], [2]string{id, args[4]})
+        return bulk(id)
+    case "XRANGE":
+        after := -1
+        if strings.HasPrefix(args[2], "(") {
+            after = streamSeq(args[2][1:])
+        }
+        var rows [][2]string
+        for _, row := range fr.streams[args[1]] {
+            if streamSeq(row[0]) > after {
+                rows = append(rows, row)
+            }
+        }
+        var out strings.Builder
+        fmt.Fprintf(&out, "*%d\r\n", len(rows))
+        for _, row := range rows {
+            out.WriteString("*2\r\n" + bulk(row[0]) + "*2\r\n" + bulk("event") + bulk(row[1]))
+        }
+        return out.String()
+    case "ZINCRBY":
+        if fr.zsets[args[1]] == nil {
+            fr.zsets[args[1]] = map[string]float64{}
+        }
+        by, _ := strconv.ParseFloat(args[2], 64)
+        fr.zsets[args[1]][args[3]] += by
+        return bulk(strconv.FormatFloat(fr.zsets[args[1]][args[3]], 'f', -1, 64))
+    case "ZREMRANGEBYSCORE":
+        lo, _ := strconv.ParseFloat(args[2], 64)
+        if args[2] == "-inf" {
+            lo = math.Inf(-1)
+        }
+        hi, _ := strconv.ParseFloat(args[3], 64)
+        n := 0
+        for m, score := range fr.zsets[args[1]] {
+            if score >= lo && score <= hi {
+                delete(fr.zsets[args[1]], m)
+                n++
+            }
+        }
+        return integer(n)
+    case "ZREVRANGE":
+        set := fr.zsets[args[1]]
+        members := make([]string, 0, len(set))
+        for m := range set {
+            members = append(members, m)
+        }
+        sort.Slice(members, func(i, j int) bool {
+            if set[members[i]] != set[members[j]] {
+                return set[members[i]] > set[members[j]]
+            }
+            return members[i] > members[j]
+        })
+        start, _ := strconv.Atoi(args[2])
+        stop, _ := strconv.Atoi(args[3])
+        if stop < 0 || stop >= len(members) {
+            stop = len(members) - 1
+        }
+        if start > stop {
+            return "*0\r\n"
+        }
+        members = members[start : stop+1]
+        var out strings.Builder
+        fmt.Fprintf(&out, "*%d\r\n", 2*len(members))
+        for _, m := range members {
+            out.WriteString(bulk(m) + bulk(strconv.FormatFloat(set[m], 'f', -1, 64)))
+        }
+        return out.String()
+    }
+    return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func streamSeq(id string) int {
+    _, seq, _ := strings.Cut(id, "-")
+    n, _ := strconv.Atoi(seq)
+    return n
+}
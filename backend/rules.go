@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Custom rounds let a host replace the classic four rounds with their own.
+// Each option wins when its predicate holds for the card revealed in that
+// round. Predicates come from a small built-in set, so uploaded rules are
+// data, never code.
+
+const maxPredicateDepth = 4
+
+// RoundRule is one custom round: a name and the options players pick from.
+type RoundRule struct {
+    Name    string       `json:"name"`
+    Options []RuleOption `json:"options"`
+}
+
+type RuleOption struct {
+    Value string    `json:"value"`
+    Label string    `json:"label,omitempty"`
+    When  Predicate `json:"when"`
+}
+
+// Predicate is evaluated against the current card and the cards before it.
+//
+//   red, black, odd, even, face, ace       the current card
+//   suit {suit}, rank {rank}               exact suit or rank
+//   higher, lower, equal                   compared with the previous card
+//   same_color, different_color            compared with the previous card
+//   between, outside                       strictly inside / outside the two previous cards
+//   not {args:[p]}, and {args}, or {args}  combinators
+type Predicate struct {
+    Op   string      `json:"op"`
+    Suit Suit        `json:"suit,omitempty"`
+    Rank int         `json:"rank,omitempty"`
+    Args []Predicate `json:"args,omitempty"`
+}
+
+// previousCardsNeeded is how many earlier cards each comparison reads.
+var previousCardsNeeded = map[string]int{
+    "red": 0, "black": 0, "odd": 0, "even": 0, "face": 0, "ace": 0, "suit": 0, "rank": 0,
+    "higher": 1, "lower": 1, "equal": 1, "same_color": 1, "different_color": 1,
+    "between": 2, "outside": 2,
+}
+
+func validateRounds(rounds []RoundRule) error {
+    if len(rounds) == 0 {
+        return nil
+    }
+    if len(rounds) != 4 {
+        return errors.New("custom rules must define exactly 4 rounds")
+    }
+    for i, r := range rounds {
+        if strings.TrimSpace(r.Name) == "" {
+            return fmt.Errorf("round %d: name required", i+1)
+        }
+        if len(r.Options) < 2 || len(r.Options) > 6 {
+            return fmt.Errorf("round %d: between 2 and 6 options required", i+1)
+        }
+        seen := map[string]bool{}
+        for _, opt := range r.Options {
+            value := normalizeGuess(opt.Value)
+            if value == "" {
+                return fmt.Errorf("round %d: option value required", i+1)
+            }
+            if seen[value] {
+                return fmt.Errorf("round %d: duplicate option %q", i+1, value)
+            }
+            seen[value] = true
+            if err := opt.When.validate(i, 1); err != nil {
+                return fmt.Errorf("round %d, option %q: %v", i+1, value, err)
+            }
+        }
+    }
+    return nil
+}
+
+func (p Predicate) validate(round, depth int) error {
+    if depth > maxPredicateDepth {
+        return errors.New("predicate nested too deeply")
+    }
+    switch p.Op {
+    case "not":
+        if len(p.Args) != 1 {
+            return errors.New("not takes exactly one argument")
+        }
+    case "and", "or":
+        if len(p.Args) < 2 {
+            return fmt.Errorf("%s takes at least two arguments", p.Op)
+        }
+    default:
+        needed, ok := previousCardsNeeded[p.Op]
+        if !ok {
+            return fmt.Errorf("unknown predicate %q", p.Op)
+        }
+        if needed > round {
+            return fmt.Errorf("%s needs %d earlier cards", p.Op, needed)
+        }
+        if p.Op == "suit" && !validSuit(p.Suit) {
+            return errors.New("suit must be hearts, diamonds, clubs or spades")
+        }
+        if p.Op == "rank" && (p.Rank < 2 || p.Rank > 14) {
+            return errors.New("rank must be between 2 and 14")
+        }
+        return nil
+    }
+    for _, arg := range p.Args {
+        if err := arg.validate(round, depth+1); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func validSuit(suit Suit) bool {
+    for _, s := range Suits {
+        if s == suit {
+            return true
+        }
+    }
+    return false
+}
+
+func isRed(c Card) bool { return c.Suit == Hearts || c.Suit == Diamonds }
+
+// eval checks the predicate against shared[round]; validation guarantees the
+// earlier cards it reads exist.
+func (p Predicate) eval(shared [4]Card, round int) bool {
+    cur := shared[round]
+    switch p.Op {
+    case "red":
+        return isRed(cur)
+    case "black":
+        return !isRed(cur)
+    case "odd":
+        return cur.Rank%2 == 1
+    case "even":
+        return cur.Rank%2 == 0
+    case "face":
+        return cur.Rank >= 11 && cur.Rank <= 13
+    case "ace":
+        return cur.Rank == 14
+    case "suit":
+        return cur.Suit == p.Suit
+    case "rank":
+        return cur.Rank == p.Rank
+    case "higher":
+        return cur.Rank > shared[round-1].Rank
+    case "lower":
+        return cur.Rank < shared[round-1].Rank
+    case "equal":
+        return cur.Rank == shared[round-1].Rank
+    case "same_color":
+        return isRed(cur) == isRed(shared[round-1])
+    case "different_color":
+        return isRed(cur) != isRed(shared[round-1])
+    case "between", "outside":
+        low, high := shared[round-2].Rank, shared[round-1].Rank
+        if low > high {
+            low, high = high, low
+        }
+        if p.Op == "between" {
+            return cur.Rank > low && cur.Rank < high
+        }
+        return cur.Rank < low || cur.Rank > high
+    case "not":
+        return !p.Args[0].eval(shared, round)
+    case "and":
+        for _, arg := range p.Args {
+            if !arg.eval(shared, round) {
+                return false
+            }
+        }
+        return true
+    case "or":
+        for _, arg := range p.Args {
+            if arg.eval(shared, round) {
+                return true
+            }
+        }
+        return false
+    default:
+        return false
+    }
+}
+
+// roundOptions lists the guesses of a round in display order, from the
+// lobby's custom rules or the classic game.
+func roundOptions(rules []RoundRule, round int) []string {
+    if len(rules) == 0 {
+        return classicOptions(round)
+    }
+    if round < 0 || round >= len(rules) {
+        return nil
+    }
+    options := make([]string, 0, len(rules[round].Options))
+    for _, opt := range rules[round].Options {
+        options = append(options, normalizeGuess(opt.Value))
+    }
+    return options
+}
+
+func validGuess(rules []RoundRule, round int, guess string) bool {
+    if len(rules) == 0 {
+        return validGuessForRound(round, guess)
+    }
+    return contains(roundOptions(rules, round), guess)
+}
+
+func correctGuess(rules []RoundRule, shared [4]Card, round int, guess string) bool {
+    if len(rules) == 0 {
+        return isCorrectGuess(shared, round, guess)
+    }
+    if round < 0 || round >= len(rules) {
+        return false
+    }
+    for _, opt := range rules[round].Options {
+        if normalizeGuess(opt.Value) == guess {
+            return opt.When.eval(shared, round)
+        }
+    }
+    return false
+}
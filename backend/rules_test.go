@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCustomRoundsReplaceClassicRules(t *testing.T) {
+    var rounds []RoundRule
+    raw := `[
+        {"name": "Odd or even", "options": [{"value": "odd", "when": {"op": "odd"}}, {"value": "even", "when": {"op": "even"}}]},
+        {"name": "Face card?", "options": [{"value": "face", "when": {"op": "face"}}, {"value": "plain", "when": {"op": "not", "args": [{"op": "face"}]}}]},
+        {"name": "Same colour", "options": [{"value": "same", "when": {"op": "same_color"}}, {"value": "different", "when": {"op": "different_color"}}]},
+        {"name": "Exact rank", "options": [{"value": "three", "when": {"op": "rank", "rank": 3}}, {"value": "other", "when": {"op": "not", "args": [{"op": "rank", "rank": 3}]}}]}
+    ]`
+    if err := json.Unmarshal([]byte(raw), &rounds); err != nil {
+        t.Fatal(err)
+    }
+
+    s := newTestSession("a")
+    s.Game.Started = false
+    if err := UpdateSettings(s, "host", LobbySettings{Rounds: rounds}); err != nil {
+        t.Fatal(err)
+    }
+    s.Game.Started = true
+
+    if err := SubmitGuess(s, "a", "red"); err == nil {
+        t.Error("expected classic guesses to be rejected")
+    }
+    if err := SubmitGuess(s, "a", "even"); err != nil {
+        t.Fatal(err)
+    }
+    if err := AdvanceRound(s); err != nil {
+        t.Fatal(err)
+    }
+    if !s.Game.Results[0].Correct {
+        t.Error("expected even to win on a 10")
+    }
+    if !correctGuess(rounds, s.Game.Shared, 2, "same") || !correctGuess(rounds, s.Game.Shared, 3, "three") {
+        t.Error("expected same colour and exact rank to evaluate against the dealt cards")
+    }
+
+    bad := []RoundRule{rounds[2], rounds[1], rounds[0], rounds[3]}
+    if err := validateRounds(bad); err == nil {
+        t.Error("expected a first round comparing with a previous card to be rejected")
+    }
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+    CapActionTapOut   = "tap_out"
+    CapActionRedirect = "redirect"
+
+    EventSafetyCap  = "safety_cap_reached"
+    EventBACWarning = "bac_warning"
+
+    DrinkReasonRedirected = "redirected"
+)
+
+// Widmark estimate constants: ethanol density (g/ml), average body water
+// ratio and elimination rate (% per hour).
+const (
+    ethanolDensity  = 0.789
+    widmarkRatio    = 0.6
+    eliminationRate = 0.015
+)
+
+// SafetySettings caps how much a single player can be assigned. Zero caps
+// mean no limit.
+type SafetySettings struct {
+    MaxPerGame int    `json:"maxPerGame"`
+    MaxPerHour int    `json:"maxPerHour"`
+    OnCap      string `json:"onCap"` // tap_out | redirect
+    // BACWarnAt enables estimated-BAC warnings (in %, e.g. 0.08) for
+    // players who entered their weight and drink size.
+    BACWarnAt float64 `json:"bacWarnAt"`
+}
+
+// DrinkProfile is what a player enters on their phone for BAC estimates.
+type DrinkProfile struct {
+    WeightKg   float64 `json:"weightKg"`
+    SipMl      float64 `json:"sipMl"`
+    ABVPercent float64 `json:"abvPercent"`
+}
+
+func (ss SafetySettings) validate() error {
+    if ss.MaxPerGame < 0 || ss.MaxPerHour < 0 {
+        return errors.New("caps must not be negative")
+    }
+    if ss.OnCap != "" && ss.OnCap != CapActionTapOut && ss.OnCap != CapActionRedirect {
+        return errors.New("onCap must be tap_out or redirect")
+    }
+    if ss.BACWarnAt < 0 {
+        return errors.New("bacWarnAt must not be negative")
+    }
+    return nil
+}
+
+func (dp DrinkProfile) validate() error {
+    if dp.WeightKg < 30 || dp.WeightKg > 300 {
+        return errors.New("weight must be between 30 and 300 kg")
+    }
+    if dp.SipMl <= 0 || dp.SipMl > 500 {
+        return errors.New("sip size must be between 1 and 500 ml")
+    }
+    if dp.ABVPercent <= 0 || dp.ABVPercent > 100 {
+        return errors.New("abv must be between 0 and 100")
+    }
+    return nil
+}
+
+// drinkAllowance is how many more sips the player may be assigned right now;
+// limited is false when no cap applies.
+func drinkAllowance(s *Session, playerID string) (left int, limited bool) {
+    safety := s.Settings.Safety
+    if safety.MaxPerGame > 0 {
+        left, limited = safety.MaxPerGame-drinksTaken(s, playerID, s.GamesPlayed), true
+    }
+    if safety.MaxPerHour > 0 {
+        since := s.now().Add(-time.Hour)
+        lastHour := 0
+        for _, e := range s.Ledger {
+            if e.ToID == playerID && e.At.After(since) {
+                lastHour += e.Amount
+            }
+        }
+        if hourLeft := safety.MaxPerHour - lastHour; !limited || hourLeft < left {
+            left, limited = hourLeft, true
+        }
+    }
+    if left < 0 {
+        left = 0
+    }
+    return left, limited
+}
+
+// redirectTarget picks the active player with the most room under the caps,
+// excluding the capped player, the giver and the giver's teammates.
+func redirectTarget(s *Session, cappedID, fromID string) string {
+    best, bestLeft := "", 0
+    for _, pid := range distributionTargets(s) {
+        if pid == cappedID || pid == fromID || sameTeam(s, fromID, pid) {
+            continue
+        }
+        left, limited := drinkAllowance(s, pid)
+        if !limited {
+            return pid
+        }
+        if left > bestLeft {
+            best, bestLeft = pid, left
+        }
+    }
+    return best
+}
+
+// applyDrinkCap splits an assignment into the part the player may still take
+// and the overflow. When the cap is hit the host is notified and, depending
+// on the settings, the player is tapped out.
+func applyDrinkCap(s *Session, toID string, amount int) (allowed, overflow int) {
+    left, limited := drinkAllowance(s, toID)
+    if !limited || amount <= left {
+        return amount, 0
+    }
+    allowed, overflow = left, amount-left
+
+    name := ""
+    if p := findPlayer(s, toID); p != nil {
+        name = p.Name
+    }
+    s.emit(EventSafetyCap, map[string]any{
+        "playerId":   toID,
+        "playerName": name,
+        "action":     s.Settings.Safety.OnCap,
+        "dropped":    overflow,
+    })
+    if s.Settings.Safety.OnCap == CapActionTapOut {
+        removeActivePlayer(s, toID)
+    }
+    return allowed, overflow
+}
+
+func removeActivePlayer(s *Session, playerID string) {
+    kept := s.Game.ActivePlayers[:0]
+    for _, pid := range s.Game.ActivePlayers {
+        if pid != playerID {
+            kept = append(kept, pid)
+        }
+    }
+    s.Game.ActivePlayers = kept
+    delete(s.Game.PendingTapOutByPlayer, playerID)
+    setPlayerStatus(s, playerID, PlayerTappedOut)
+}
+
+// estimatedBAC applies the Widmark formula to everything the player drank in
+// this session. It returns 0 when the player has not entered a profile.
+func estimatedBAC(s *Session, p *Player) float64 {
+    if p.DrinkProfile == nil || p.DrinkProfile.WeightKg <= 0 {
+        return 0
+    }
+    var first time.Time
+    sips := 0
+    for _, e := range s.Ledger {
+        if e.ToID != p.ID {
+            continue
+        }
+        if first.IsZero() || e.At.Before(first) {
+            first = e.At
+        }
+        sips += e.Amount
+    }
+    if sips == 0 {
+        return 0
+    }
+    grams := float64(sips) * p.DrinkProfile.SipMl * p.DrinkProfile.ABVPercent / 100 * ethanolDensity
+    bac := grams/(p.DrinkProfile.WeightKg*1000*widmarkRatio)*100 - eliminationRate*s.now().Sub(first).Hours()
+    if bac < 0 {
+        return 0
+    }
+    return bac
+}
+
+// checkBAC warns the host and the player the first time an estimate crosses
+// the configured threshold.
+func checkBAC(s *Session, playerID string) {
+    warnAt := s.Settings.Safety.BACWarnAt
+    p := findPlayer(s, playerID)
+    if warnAt <= 0 || p == nil || p.BACWarned || !s.Settings.Penalty.unit().Alcohol {
+        return
+    }
+    bac := estimatedBAC(s, p)
+    if bac < warnAt {
+        return
+    }
+    p.BACWarned = true
+    s.emit(EventBACWarning, map[string]any{
+        "playerId":     p.ID,
+        "playerName":   p.Name,
+        "estimatedBac": bac,
+    })
+}
+
+func SetDrinkProfile(s *Session, playerID string, dp DrinkProfile) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    p := findPlayer(s, playerID)
+    if p == nil {
+        return errPlayerNotInSession
+    }
+    if err := dp.validate(); err != nil {
+        return err
+    }
+    p.DrinkProfile = &dp
+    p.BACWarned = false
+    checkBAC(s, playerID)
+    return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestDrinkCapRedirectsOverflow(t *testing.T) {
+    s := newTestSession("a", "b", "c")
+    s.Settings.Safety = SafetySettings{MaxPerGame: 5, OnCap: CapActionRedirect}
+
+    chargeDrink(s, "a", "b", 8, DrinkReasonGiven)
+
+    if got := drinksTaken(s, "a", s.GamesPlayed); got != 5 {
+        t.Errorf("expected a capped at 5, got %d", got)
+    }
+    if got := drinksTaken(s, "c", s.GamesPlayed); got != 3 {
+        t.Errorf("expected 3 redirected to c, got %d", got)
+    }
+    if got := findPlayer(s, "b").GivenOut; got != 8 {
+        t.Errorf("expected b to be credited 8 given, got %d", got)
+    }
+}
+
+func TestDrinkCapNeverRedirectsToGiversTeam(t *testing.T) {
+    s := newTestSession("a", "b", "c", "d")
+    s.Teams = []Team{{ID: "t1", Members: []string{"b", "c"}}, {ID: "t2", Members: []string{"a", "d"}}}
+    s.Settings.Safety = SafetySettings{MaxPerGame: 5, OnCap: CapActionRedirect}
+
+    chargeDrink(s, "a", "b", 8, DrinkReasonGiven)
+
+    if got := drinksTaken(s, "c", s.GamesPlayed); got != 0 {
+        t.Errorf("expected b's teammate c to be spared, got %d", got)
+    }
+    if got := drinksTaken(s, "d", s.GamesPlayed); got != 3 {
+        t.Errorf("expected 3 redirected to d, got %d", got)
+    }
+}
+
+func TestDrinkCapExactlyReachedIsNotACap(t *testing.T) {
+    s := newTestSession("a")
+    s.Settings.Safety = SafetySettings{MaxPerGame: 2, OnCap: CapActionTapOut}
+
+    chargeDrink(s, "a", "", 2, DrinkReasonLostRound)
+    for _, ev := range s.drainEvents() {
+        if ev.Type == EventSafetyCap {
+            t.Errorf("expected no cap event when the cap is only reached, got %+v", ev)
+        }
+    }
+    if len(s.Game.ActivePlayers) != 1 {
+        t.Error("expected a to keep playing at the cap")
+    }
+}
+
+func TestLostRoundRecordsWhatWasCharged(t *testing.T) {
+    s := newTestSession("a")
+    s.Settings.Safety = SafetySettings{MaxPerGame: 1}
+
+    playRound(t, s, map[string]string{"a": "black"})
+
+    if r := s.Game.Results[0]; r.Stake != 2 || r.Drank != 1 {
+        t.Errorf("expected a stake of 2 with 1 drunk under the cap, got %+v", r)
+    }
+}
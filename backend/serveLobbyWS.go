@@ -98,7 +98,10 @@ func (h *lobbyHub) broadcastSession(code string, session *Session) {
     if err != nil {
         return
     }
+    h.broadcastRaw(code, payload)
+}
 
+func (h *lobbyHub) broadcastRaw(code string, payload []byte) {
     h.mu.RLock()
     code = normalizeCode(code)
     room := h.clients[code]
@@ -116,6 +119,18 @@ func (h *lobbyHub) broadcastSession(code string, session *Session) {
     }
 }
 
+func (h *lobbyHub) broadcastEvent(ev lobbyEvent) {
+    payload, err := json.Marshal(map[string]any{
+        "type": ev.Type,
+        "unit": ev.Unit,
+        "data": ev.Data,
+    })
+    if err != nil {
+        return
+    }
+    h.broadcastRaw(ev.Code, payload)
+}
+
 var upgrader = websocket.Upgrader{
     CheckOrigin: func(r *http.Request) bool {
         allowedOrigin := os.Getenv("ALLOWED_ORIGIN")
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// A session is stored as a snapshot (session:{code}) plus an append-only
+// Redis Stream of events (events:{code}). The live state is the snapshot
+// with every later event folded on top through applyEvent; a fresh snapshot
+// is written every snapshotEvery events.
+const snapshotEvery = 10
+
+const (
+    SessionEventPlayerJoined          = "player_joined"
+    SessionEventClosed                = "session_closed"
+    SessionEventGameStarted           = "game_started"
+    SessionEventGuessSubmitted        = "guess_submitted"
+    SessionEventTapOutRequested       = "tap_out_requested"
+    SessionEventRoundAdvanced         = "round_advanced"
+    SessionEventDrinksDistributed     = "drinks_distributed"
+    SessionEventDistributionFinalized = "distribution_finalized"
+    SessionEventUndone                = "undone"
+    SessionEventPaused                = "paused"
+    SessionEventResumed               = "resumed"
+    SessionEventSettingsUpdated       = "settings_updated"
+    SessionEventDrinksAcked           = "drinks_acked"
+    SessionEventDrinkProfileSet       = "drink_profile_set"
+    SessionEventTeamCreated           = "team_created"
+    SessionEventTeamAssigned          = "team_assigned"
+    SessionEventBotAdded              = "bot_added"
+    SessionEventLetItRide             = "let_it_ride"
+    SessionEventTapOutCancelled       = "tap_out_cancelled"
+    SessionEventSideBetPlaced         = "side_bet_placed"
+)
+
+// SessionEvent carries everything needed to re-apply a mutation exactly,
+// including the outcome of any randomness (dealt cards, finalize targets).
+type SessionEvent struct {
+    ID          string            `json:"id,omitempty"`
+    Type        string            `json:"type"`
+    At          time.Time         `json:"at"`
+    PlayerID    string            `json:"playerId,omitempty"`
+    Player      *Player           `json:"player,omitempty"`
+    Guess       string            `json:"guess,omitempty"`
+    Cards       []Card            `json:"cards,omitempty"`
+    Challenges  []string          `json:"challenges,omitempty"`
+    Allocations map[string]int    `json:"allocations,omitempty"`
+    Assignments []DrinkAssignment `json:"assignments,omitempty"`
+    Grace       time.Duration     `json:"grace,omitempty"`
+    Settings    *LobbySettings    `json:"settings,omitempty"`
+    IDs         []string          `json:"ids,omitempty"`
+    Drink       *DrinkProfile     `json:"drink,omitempty"`
+    Team        *Team             `json:"team,omitempty"`
+    TargetID    string            `json:"targetId,omitempty"`
+    Captain     bool              `json:"captain,omitempty"`
+    Bet         *SideBet          `json:"bet,omitempty"`
+}
+
+func newSessionEvent(eventType string) SessionEvent {
+    return SessionEvent{Type: eventType, At: time.Now().UTC()}
+}
+
+func eventsKey(code string) string { return "events:" + normalizeCode(code) }
+
+// applyEvent folds one event into the session. It is used both for new
+// mutations and when rebuilding state from the stream.
+func applyEvent(s *Session, ev SessionEvent) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    s.clock = ev.At
+    defer func() { s.clock = time.Time{} }()
+
+    if undoableEvents[ev.Type] {
+        point, err := captureUndoPoint(s, ev.Type)
+        if err != nil {
+            return err
+        }
+        if err := applyEventType(s, ev); err != nil {
+            return err
+        }
+        s.Undo = point
+        return nil
+    }
+    return applyEventType(s, ev)
+}
+
+func applyEventType(s *Session, ev SessionEvent) error {
+    switch ev.Type {
+    case SessionEventPlayerJoined:
+        if ev.Player == nil {
+            return errPlayerRequired
+        }
+        if s.Status != "active" {
+            return errSessionClosing
+        }
+        s.Players = append(s.Players, *ev.Player)
+    case SessionEventClosed:
+        t := ev.At.Add(ev.Grace)
+        s.Status = "closing"
+        s.ShuttingDownAt = &t
+    case SessionEventGameStarted:
+        return startGameWithCards(s, ev.Cards, ev.Challenges)
+    case SessionEventGuessSubmitted:
+        return SubmitGuess(s, ev.PlayerID, ev.Guess)
+    case SessionEventTapOutRequested:
+        return TapOut(s, ev.PlayerID)
+    case SessionEventRoundAdvanced:
+        return AdvanceRound(s)
+    case SessionEventDrinksDistributed:
+        return DistributeDrinks(s, ev.PlayerID, ev.Allocations)
+    case SessionEventDistributionFinalized:
+        return applyFinalizeDistribution(s, ev.Assignments)
+    case SessionEventUndone:
+        return UndoLast(s, ev.PlayerID)
+    case SessionEventPaused:
+        return PauseGame(s, ev.PlayerID)
+    case SessionEventResumed:
+        return ResumeGame(s, ev.PlayerID)
+    case SessionEventSettingsUpdated:
+        if ev.Settings == nil {
+            return errors.New("settings required")
+        }
+        return UpdateSettings(s, ev.PlayerID, *ev.Settings)
+    case SessionEventDrinksAcked:
+        return AckDrinks(s, ev.PlayerID, ev.IDs)
+    case SessionEventDrinkProfileSet:
+        if ev.Drink == nil {
+            return errors.New("drink profile required")
+        }
+        return SetDrinkProfile(s, ev.PlayerID, *ev.Drink)
+    case SessionEventTapOutCancelled:
+        return CancelTapOut(s, ev.PlayerID)
+    case SessionEventLetItRide:
+        return LetItRide(s, ev.PlayerID)
+    case SessionEventSideBetPlaced:
+        if ev.Bet == nil {
+            return errors.New("bet required")
+        }
+        return PlaceSideBet(s, *ev.Bet)
+    case SessionEventBotAdded:
+        if ev.Player == nil {
+            return errPlayerRequired
+        }
+        return AddBot(s, ev.PlayerID, *ev.Player)
+    case SessionEventTeamCreated:
+        if ev.Team == nil {
+            return errors.New("team required")
+        }
+        return CreateTeam(s, ev.PlayerID, *ev.Team)
+    case SessionEventTeamAssigned:
+        team := ""
+        if ev.Team != nil {
+            team = ev.Team.ID
+        }
+        return AssignTeam(s, ev.PlayerID, ev.TargetID, team, ev.Captain)
+    default:
+        return errors.New("unknown event type: " + ev.Type)
+    }
+    return nil
+}
+
+func (s *RedisStore) readEvents(code, after string) ([]SessionEvent, error) {
+    start := "-"
+    if after != "" {
+        start = "(" + after
+    }
+    msgs, err := s.rdb.XRange(s.ctx, eventsKey(code), start, "+").Result()
+    if err != nil {
+        return nil, err
+    }
+    events := make([]SessionEvent, 0, len(msgs))
+    for _, msg := range msgs {
+        raw, _ := msg.Values["event"].(string)
+        var ev SessionEvent
+        if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+            continue
+        }
+        ev.ID = msg.ID
+        events = append(events, ev)
+    }
+    return events, nil
+}
+
+// Events returns the full event stream of a lobby, oldest first.
+func (s *RedisStore) Events(code string) ([]SessionEvent, error) {
+    return s.readEvents(code, "")
+}
+
+// apply validates ev against the current state and, if it applies, appends
+// it to the stream. The session is updated in place.
+func (s *RedisStore) apply(session *Session, ev SessionEvent) error {
+    if err := applyEvent(session, ev); err != nil {
+        return err
+    }
+    return s.commit(session, ev, s.ttl)
+}
+
+func (s *RedisStore) commit(session *Session, ev SessionEvent, ttl time.Duration) error {
+    if session.Ended {
+        return errLobbyEnded
+    }
+    ev.ID = ""
+    b, _ := json.Marshal(ev)
+    id, err := s.rdb.XAdd(s.ctx, &redis.XAddArgs{
+        Stream: eventsKey(session.Code),
+        Values: map[string]any{"event": b},
+    }).Result()
+    if err != nil {
+        return err
+    }
+    session.Version = id
+    session.sinceSnapshot++
+
+    if session.sinceSnapshot >= snapshotEvery || ttl != s.ttl {
+        if err := s.writeSnapshot(session, ttl); err != nil {
+            return err
+        }
+    } else {
+        _, err := s.rdb.Pipelined(s.ctx, func(pipe redis.Pipeliner) error {
+            pipe.Expire(s.ctx, sessionKey(session.Code), ttl)
+            pipe.Expire(s.ctx, eventsKey(session.Code), ttl)
+            holdCode(s, pipe, session, ttl)
+            return nil
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    // The event is committed at this point; derived data must not fail it.
+    if err := s.recordProfileStats(session); err != nil {
+        log.Printf("lobby %s: recording profile stats: %v", session.Code, err)
+    }
+    if ev.Type == SessionEventUndone {
+        if err := s.revertProfileStats(session); err != nil {
+            log.Printf("lobby %s: reverting profile stats: %v", session.Code, err)
+        }
+    }
+    if err := s.appendHistory(session); err != nil {
+        log.Printf("lobby %s: appending history: %v", session.Code, err)
+    }
+    return nil
+}
+
+func (s *RedisStore) writeSnapshot(session *Session, ttl time.Duration) error {
+    b, _ := json.Marshal(session)
+    _, err := s.rdb.Pipelined(s.ctx, func(pipe redis.Pipeliner) error {
+        pipe.Set(s.ctx, sessionKey(session.Code), b, ttl)
+        pipe.Expire(s.ctx, eventsKey(session.Code), ttl)
+        holdCode(s, pipe, session, ttl)
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    session.sinceSnapshot = 0
+    return nil
+}
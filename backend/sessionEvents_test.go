@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// replayView strips what legitimately differs between a session mutated in
+// memory and one rebuilt from Redis: timestamps, the stream version and the
+// undo point recorded by applyEvent.
+func replayView(t *testing.T, s *Session) any {
+    t.Helper()
+    b, err := json.Marshal(struct {
+        Players     []Player      `json:"players"`
+        Game        GameState     `json:"game"`
+        Ledger      []LedgerEntry `json:"ledger"`
+        GamesPlayed int           `json:"gamesPlayed"`
+        DrinkSeq    int           `json:"drinkSeq"`
+    }{s.Players, s.Game, s.Ledger, s.GamesPlayed, s.DrinkSeq})
+    if err != nil {
+        t.Fatal(err)
+    }
+    var out any
+    if err := json.Unmarshal(b, &out); err != nil {
+        t.Fatal(err)
+    }
+    return stripTimes(out)
+}
+
+func stripTimes(v any) any {
+    switch v := v.(type) {
+    case map[string]any:
+        for k, x := range v {
+            v[k] = stripTimes(x)
+        }
+    case []any:
+        for i, x := range v {
+            v[i] = stripTimes(x)
+        }
+    case string:
+        if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+            return ""
+        }
+    }
+    return v
+}
+
+func TestSnapshotPlusStreamMatchesDirectMutation(t *testing.T) {
+    store, _ := newTestStore(t)
+
+    created, host, err := store.CreateSession("Host", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    code := created.Code
+    direct := &Session{HostID: host.ID, Code: code, Status: "active", Players: []Player{host}}
+
+    for _, name := range []string{"A", "B", "C"} {
+        player, _, err := store.JoinSession(code, name, "")
+        if err != nil {
+            t.Fatal(err)
+        }
+        direct.Players = append(direct.Players, player)
+    }
+    live, err := store.StartSession(code)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := startGameWithCards(direct, live.Game.Shared[:], live.Game.ChallengeDeck); err != nil {
+        t.Fatal(err)
+    }
+
+    // Same steps on both sides; whatever one side rejects the other must too.
+    step := func(name string, viaStore func() (*Session, error), inMemory func() error) {
+        t.Helper()
+        _, storeErr := viaStore()
+        directErr := inMemory()
+        if (storeErr == nil) != (directErr == nil) {
+            t.Fatalf("%s: store err %v, direct err %v", name, storeErr, directErr)
+        }
+    }
+    pause := func() {
+        step("pause", func() (*Session, error) { return store.Pause(code, host.ID) }, func() error { return PauseGame(direct, host.ID) })
+        step("resume", func() (*Session, error) { return store.Resume(code, host.ID) }, func() error { return ResumeGame(direct, host.ID) })
+    }
+    pause()
+    pause()
+    guesses := [][]string{{"red", "black", "red"}, {"higher", "lower", "higher"}, {"between", "outside", "outside"}}
+    for _, round := range guesses {
+        for i, p := range direct.Players[1:] {
+            guess := round[i]
+            id := p.ID
+            step("guess", func() (*Session, error) { return store.SubmitGuess(code, id, guess) }, func() error { return SubmitGuess(direct, id, guess) })
+        }
+        step("advance", func() (*Session, error) { return store.AdvanceRound(code) }, func() error { return AdvanceRound(direct) })
+    }
+
+    // How many events the rounds produced depends on the deal; late joins
+    // always apply, so add them until a snapshot has a live tail behind it.
+    tail := func() (Session, []SessionEvent) {
+        t.Helper()
+        raw, err := store.rdb.Get(store.ctx, sessionKey(code)).Result()
+        if err != nil {
+            t.Fatal(err)
+        }
+        var snapshot Session
+        if err := json.Unmarshal([]byte(raw), &snapshot); err != nil {
+            t.Fatal(err)
+        }
+        events, err := store.readEvents(code, snapshot.Version)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return snapshot, events
+    }
+    for i := 0; ; i++ {
+        if snapshot, events := tail(); snapshot.Version != "" && len(events) > 0 {
+            break
+        }
+        if i == snapshotEvery {
+            t.Fatal("expected a snapshot within snapshotEvery events")
+        }
+        player, _, err := store.JoinSession(code, "Late", "")
+        if err != nil {
+            t.Fatal(err)
+        }
+        direct.Players = append(direct.Players, player)
+    }
+
+    // An event that no longer applies is skipped on replay, as it was live.
+    ghost, _ := json.Marshal(SessionEvent{Type: SessionEventGuessSubmitted, At: time.Now().UTC(), PlayerID: "ghost", Guess: "red"})
+    if err := store.rdb.XAdd(store.ctx, &redis.XAddArgs{Stream: eventsKey(code), Values: map[string]any{"event": ghost}}).Err(); err != nil {
+        t.Fatal(err)
+    }
+
+    if snapshot, events := tail(); len(events) < 2 {
+        t.Fatalf("expected the tail after snapshot %q to hold a live and a stale event, got %d", snapshot.Version, len(events))
+    }
+
+    rebuilt, ok := store.GetSession(code)
+    if !ok {
+        t.Fatal("session not found")
+    }
+    if got, want := replayView(t, rebuilt), replayView(t, direct); !reflect.DeepEqual(got, want) {
+        a, _ := json.Marshal(got)
+        b, _ := json.Marshal(want)
+        t.Fatalf("rebuilt session diverged:\nrebuilt: %s\ndirect:  %s", a, b)
+    }
+}
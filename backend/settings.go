@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+)
+
+// LobbySettings holds the host-tunable rules of a lobby. Zero values are the
+// classic game, so older sessions without settings behave as before.
+type LobbySettings struct {
+    // RequireDrinkAck blocks a player's next guess until they have
+    // acknowledged every pending drink.
+    RequireDrinkAck bool `json:"requireDrinkAck"`
+
+    // DistributeEachRound opens a short distribution window after every
+    // round instead of only after the last one.
+    DistributeEachRound bool `json:"distributeEachRound"`
+
+    // Locale picks the language of generated words and server messages.
+    Locale string `json:"locale,omitempty"`
+
+    // Rounds replaces the classic four rounds with host-defined rules.
+    Rounds []RoundRule `json:"rounds,omitempty"`
+
+    // TrainingWheels shows every player the exact odds of each option.
+    TrainingWheels bool `json:"trainingWheels"`
+
+    Safety    SafetySettings  `json:"safety"`
+    Penalty   PenaltySettings `json:"penalty"`
+    Targeting TargetingRules  `json:"targeting"`
+    TapOut    TapOutSettings  `json:"tapOut"`
+
+    // FinalizeStrategy decides where unallocated give-outs go when the
+    // distribution window times out. Empty means random.
+    FinalizeStrategy string `json:"finalizeStrategy,omitempty"`
+
+    // TeamVoting decides how a team's guess is chosen: majority (default)
+    // or captain.
+    TeamVoting string `json:"teamVoting,omitempty"`
+}
+
+func (ls LobbySettings) validate() error {
+    if err := ls.Safety.validate(); err != nil {
+        return err
+    }
+    if err := ls.Targeting.validate(); err != nil {
+        return err
+    }
+    if err := ls.TapOut.validate(); err != nil {
+        return err
+    }
+    if !validFinalizeStrategy(ls.FinalizeStrategy) {
+        return errors.New("unknown finalize strategy")
+    }
+    if !validTeamVoting(ls.TeamVoting) {
+        return errors.New("unknown team voting rule")
+    }
+    if ls.Locale != "" && !supportedLocale(ls.Locale) {
+        return errors.New("unsupported locale")
+    }
+    if err := validateRounds(ls.Rounds); err != nil {
+        return err
+    }
+    return ls.Penalty.validate()
+}
+
+func UpdateSettings(s *Session, requesterID string, settings LobbySettings) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if requesterID != s.HostID {
+        return errHostOnlySettings
+    }
+    if err := settings.validate(); err != nil {
+        return err
+    }
+    if (s.Game.Started || s.Game.DistributionActive) && !reflect.DeepEqual(settings.Rounds, s.Settings.Rounds) {
+        return errors.New("rounds can only change between games")
+    }
+    s.Settings = settings
+    refreshOdds(s)
+    return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+const (
+    PlayerActive     = "active"
+    PlayerTappedOut  = "tapped_out"
+    PlayerEliminated = "eliminated"
+
+    DrinkReasonTapOut = "tap_out"
+)
+
+// TapOutSettings are the lobby's rules for leaving a game early.
+type TapOutSettings struct {
+    // Penalty is drunk when the tap-out takes effect. 0 means free.
+    Penalty int `json:"penalty"`
+    // CashOut lets a tapped-out player hand out their give-outs right away
+    // instead of waiting for the distribution window.
+    CashOut bool `json:"cashOut"`
+    // AllowCancel lets a player withdraw a pending tap-out before the round
+    // is settled.
+    AllowCancel bool `json:"allowCancel"`
+}
+
+func (ts TapOutSettings) validate() error {
+    if ts.Penalty < 0 {
+        return errNegativeTapOutPenalty
+    }
+    return nil
+}
+
+// playerStatus reports whether a player is still in the current game, tapped
+// out, or eliminated by a wrong guess.
+func playerStatus(s *Session, playerID string) string {
+    if status, ok := s.Game.StatusByPlayer[playerID]; ok {
+        return status
+    }
+    return PlayerActive
+}
+
+func setPlayerStatus(s *Session, playerID, status string) {
+    if s.Game.StatusByPlayer == nil {
+        s.Game.StatusByPlayer = map[string]string{}
+    }
+    s.Game.StatusByPlayer[playerID] = status
+}
+
+func CancelTapOut(s *Session, playerID string) error {
+    if err := requireOpenRound(s); err != nil {
+        return err
+    }
+    if !s.Settings.TapOut.AllowCancel {
+        return errTapOutCancelDisabled
+    }
+    if !s.Game.PendingTapOutByPlayer[playerID] {
+        return errNoTapOutPending
+    }
+    delete(s.Game.PendingTapOutByPlayer, playerID)
+    s.logHistory(HistoryEntry{Type: HistoryTapOut, Round: s.Game.Round, PlayerID: playerID, Action: "cancel"})
+    return nil
+}
+
+// applyTapOut takes a player out of the game at the end of a round they
+// survived, charging the lobby's tap-out penalty.
+func applyTapOut(s *Session, playerID string) {
+    setPlayerStatus(s, playerID, PlayerTappedOut)
+    if penalty := s.Settings.TapOut.Penalty; penalty > 0 {
+        chargeDrink(s, playerID, "", penalty, DrinkReasonTapOut)
+    }
+}
+
+// canCashOut reports whether the player may distribute outside the normal
+// window: a tapped-out player in a running game with cash-out enabled.
+func canCashOut(s *Session, playerID string) bool {
+    return s.Settings.TapOut.CashOut && s.Game.Started && playerStatus(s, playerID) == PlayerTappedOut
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestTapOutPenaltyAndCashOut(t *testing.T) {
+    s := newTestSession("a", "b", "c")
+    s.Settings.TapOut = TapOutSettings{Penalty: 1, CashOut: true, AllowCancel: true}
+
+    for _, pid := range []string{"a", "b"} {
+        if err := TapOut(s, pid); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := CancelTapOut(s, "b"); err != nil {
+        t.Fatal(err)
+    }
+    playRound(t, s, map[string]string{"a": "red", "b": "red", "c": "black"})
+
+    if got := playerStatus(s, "a"); got != PlayerTappedOut {
+        t.Errorf("expected a tapped out, got %q", got)
+    }
+    if got := playerStatus(s, "c"); got != PlayerEliminated {
+        t.Errorf("expected c eliminated, got %q", got)
+    }
+    if got := drinksTaken(s, "a", s.GamesPlayed); got != 1 {
+        t.Errorf("expected a to pay the tap-out penalty, got %d", got)
+    }
+    if err := DistributeDrinks(s, "a", map[string]int{"b": 2}); err != nil {
+        t.Fatalf("expected a to cash out mid-game: %v", err)
+    }
+    if err := DistributeDrinks(s, "b", map[string]int{"a": 1}); err == nil {
+        t.Error("expected active players to wait for the distribution window")
+    }
+}
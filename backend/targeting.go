@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"sort"
+)
+
+const (
+    DrinkReasonRevenge = "revenge"
+
+    EventRevenge = "revenge"
+)
+
+// TargetingRules constrain who a giver may pile drinks on.
+type TargetingRules struct {
+    // MaxSharePercent caps how much of a giver's give-outs in one game may
+    // go to a single target. 0 disables the rule.
+    MaxSharePercent int `json:"maxSharePercent"`
+    // Protected players cannot be targeted by give-outs at all.
+    Protected []string `json:"protected,omitempty"`
+    // RevengeBonus adds extra sips when giving back to whoever last gave
+    // to you. 0 disables the rule.
+    RevengeBonus int `json:"revengeBonus"`
+}
+
+func (tr TargetingRules) validate() error {
+    if tr.MaxSharePercent < 0 || tr.MaxSharePercent > 100 {
+        return errors.New("maxSharePercent must be between 0 and 100")
+    }
+    if tr.RevengeBonus < 0 {
+        return errors.New("revengeBonus must not be negative")
+    }
+    return nil
+}
+
+func isProtected(s *Session, playerID string) bool {
+    for _, pid := range s.Settings.Targeting.Protected {
+        if pid == playerID {
+            return true
+        }
+    }
+    return false
+}
+
+func playerName(s *Session, playerID string) string {
+    if p := findPlayer(s, playerID); p != nil {
+        return p.Name
+    }
+    return "that player"
+}
+
+// maxShareFor is how many sips the giver may send one target this game, or
+// -1 when the share rule is off.
+func maxShareFor(s *Session, giverID string) int {
+    pct := s.Settings.Targeting.MaxSharePercent
+    if pct <= 0 {
+        return -1
+    }
+    earned := s.Game.GiveOutRemainingByPlayer[giverID] + drinksGiven(s, giverID, s.GamesPlayed)
+    limit := earned * pct / 100
+    if limit < 1 {
+        limit = 1
+    }
+    return limit
+}
+
+func givenTo(s *Session, giverID, targetID string) int {
+    total := 0
+    for _, e := range s.Ledger {
+        if e.Game == s.GamesPlayed && e.FromID == giverID && e.ToID == targetID && givenByGiver(e) {
+            total += e.Amount
+        }
+    }
+    return total
+}
+
+// checkTargeting validates an allocation against the lobby's targeting rules.
+func checkTargeting(s *Session, giverID string, allocations map[string]int) error {
+    limit := maxShareFor(s, giverID)
+    for _, targetID := range sortedKeys(allocations) {
+        amount := allocations[targetID]
+        if amount <= 0 {
+            continue
+        }
+        if isProtected(s, targetID) {
+            return codedErrorf("protected_target", "%s is protected and cannot be given drinks", playerName(s, targetID))
+        }
+        if limit >= 0 {
+            if already := givenTo(s, giverID, targetID); already+amount > limit {
+                return codedErrorf("share_limit", "you can give at most %d to %s (%d%% of your give-outs); %d already given",
+                    limit, playerName(s, targetID), s.Settings.Targeting.MaxSharePercent, already)
+            }
+        }
+    }
+    return nil
+}
+
+// lastGiverTo returns who most recently handed the player a drink.
+func lastGiverTo(s *Session, playerID string) string {
+    for i := len(s.Ledger) - 1; i >= 0; i-- {
+        e := s.Ledger[i]
+        if e.ToID == playerID && e.FromID != "" && e.FromID != playerID {
+            return e.FromID
+        }
+    }
+    return ""
+}
+
+func sortedKeys(m map[string]int) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
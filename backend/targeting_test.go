@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTargetingRulesLimitGiveOuts(t *testing.T) {
+    s := newTestSession("a", "b", "c", "d")
+    s.Settings.Targeting = TargetingRules{MaxSharePercent: 50, Protected: []string{"d"}, RevengeBonus: 2}
+    s.Game.Started = false
+    s.Game.DistributionActive = true
+    s.Game.GiveOutRemainingByPlayer = map[string]int{"a": 6, "b": 4}
+
+    if err := DistributeDrinks(s, "a", map[string]int{"d": 1}); err == nil || !strings.Contains(err.Error(), "is protected") {
+        t.Errorf("expected protected player to be rejected as protected, got %v", err)
+    }
+    if err := DistributeDrinks(s, "a", map[string]int{"b": 4}); err == nil {
+        t.Error("expected share above 50% to be rejected")
+    }
+    if err := DistributeDrinks(s, "a", map[string]int{"b": 3}); err != nil {
+        t.Fatal(err)
+    }
+    if err := DistributeDrinks(s, "b", map[string]int{"a": 2}); err != nil {
+        t.Fatal(err)
+    }
+    if got := drinksTaken(s, "a", s.GamesPlayed); got != 4 {
+        t.Errorf("expected revenge bonus to bring a to 4, got %d", got)
+    }
+}
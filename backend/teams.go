@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+    TeamVotingMajority = "majority"
+    TeamVotingCaptain  = "captain"
+)
+
+// Team groups players who share one guess per round and therefore one fate.
+type Team struct {
+    ID        string   `json:"id"`
+    Name      string   `json:"name"`
+    Members   []string `json:"members"`
+    CaptainID string   `json:"captainId,omitempty"`
+}
+
+func validTeamVoting(voting string) bool {
+    return voting == "" || voting == TeamVotingMajority || voting == TeamVotingCaptain
+}
+
+func (ls LobbySettings) teamVoting() string {
+    if ls.TeamVoting == "" {
+        return TeamVotingMajority
+    }
+    return ls.TeamVoting
+}
+
+func teamMode(s *Session) bool {
+    return len(s.Teams) > 0
+}
+
+func findTeam(s *Session, teamID string) *Team {
+    for i := range s.Teams {
+        if s.Teams[i].ID == teamID {
+            return &s.Teams[i]
+        }
+    }
+    return nil
+}
+
+// teamOf returns the player's team, or nil if they play on their own.
+func teamOf(s *Session, playerID string) *Team {
+    for i := range s.Teams {
+        for _, pid := range s.Teams[i].Members {
+            if pid == playerID {
+                return &s.Teams[i]
+            }
+        }
+    }
+    return nil
+}
+
+func sameTeam(s *Session, a, b string) bool {
+    t := teamOf(s, a)
+    return t != nil && t == teamOf(s, b)
+}
+
+func CreateTeam(s *Session, requesterID string, team Team) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if requesterID != s.HostID {
+        return errHostOnlyTeams
+    }
+    if s.Game.Started || s.Game.DistributionActive {
+        return errTeamsLocked
+    }
+    team.Name = strings.TrimSpace(team.Name)
+    if team.Name == "" {
+        return errTeamNameRequired
+    }
+    if team.ID == "" {
+        return errTeamIDRequired
+    }
+    for _, t := range s.Teams {
+        if strings.EqualFold(t.Name, team.Name) {
+            return errTeamNameTaken
+        }
+    }
+    s.Teams = append(s.Teams, Team{ID: team.ID, Name: team.Name, Members: []string{}})
+    return nil
+}
+
+// AssignTeam moves a player onto a team; an empty teamID removes them from
+// their team.
+func AssignTeam(s *Session, requesterID, playerID, teamID string, captain bool) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if requesterID != s.HostID {
+        return errHostOnlyTeams
+    }
+    if s.Game.Started || s.Game.DistributionActive {
+        return errTeamsLocked
+    }
+    if playerID == s.HostID || !hasPlayer(s, playerID) {
+        return errPlayerNotInSession
+    }
+    var target *Team
+    if teamID != "" {
+        if target = findTeam(s, teamID); target == nil {
+            return errTeamNotFound
+        }
+    }
+
+    if current := teamOf(s, playerID); current != nil && current != target {
+        members := current.Members[:0]
+        for _, pid := range current.Members {
+            if pid != playerID {
+                members = append(members, pid)
+            }
+        }
+        current.Members = members
+        if current.CaptainID == playerID {
+            current.CaptainID = ""
+        }
+    }
+    if target != nil {
+        if teamOf(s, playerID) != target {
+            target.Members = append(target.Members, playerID)
+        }
+        if captain {
+            target.CaptainID = playerID
+        } else if target.CaptainID == playerID {
+            target.CaptainID = ""
+        }
+    }
+    return nil
+}
+
+// teamGuess resolves the guess that counts for playerID this round. Outside
+// team mode that is simply their own guess.
+func teamGuess(s *Session, playerID string, round int) string {
+    team := teamOf(s, playerID)
+    if team == nil {
+        return guessAt(s, playerID, round)
+    }
+    active := activeMembers(s, team)
+
+    if s.Settings.teamVoting() == TeamVotingCaptain && contains(active, team.CaptainID) {
+        return guessAt(s, team.CaptainID, round)
+    }
+
+    votes := map[string]int{}
+    for _, pid := range active {
+        if g := guessAt(s, pid, round); g != "" {
+            votes[g]++
+        }
+    }
+    options := make([]string, 0, len(votes))
+    for g := range votes {
+        options = append(options, g)
+    }
+    sort.Strings(options)
+
+    // Ties go to the captain's vote, then alphabetically.
+    best := ""
+    captainVote := guessAt(s, team.CaptainID, round)
+    for _, g := range options {
+        switch {
+        case best == "", votes[g] > votes[best]:
+            best = g
+        case votes[g] == votes[best] && g == captainVote:
+            best = g
+        }
+    }
+    return best
+}
+
+// teamDecided reports whether the team's guess for this round is final.
+func teamDecided(s *Session, team *Team) bool {
+    active := activeMembers(s, team)
+    round := s.Game.Round
+    if s.Settings.teamVoting() == TeamVotingCaptain && contains(active, team.CaptainID) {
+        return guessAt(s, team.CaptainID, round) != ""
+    }
+    for _, pid := range active {
+        if guessAt(s, pid, round) == "" {
+            return false
+        }
+    }
+    return true
+}
+
+func activeMembers(s *Session, team *Team) []string {
+    var active []string
+    for _, pid := range team.Members {
+        if contains(s.Game.ActivePlayers, pid) {
+            active = append(active, pid)
+        }
+    }
+    return active
+}
+
+func guessAt(s *Session, playerID string, round int) string {
+    guesses := s.Game.Guesses[playerID]
+    if len(guesses) > round {
+        return guesses[round]
+    }
+    return ""
+}
+
+func contains(ids []string, id string) bool {
+    if id == "" {
+        return false
+    }
+    for _, v := range ids {
+        if v == id {
+            return true
+        }
+    }
+    return false
+}
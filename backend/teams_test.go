@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestTeamMajorityDecidesSharedFate(t *testing.T) {
+    s := &Session{HostID: "host", Code: "TEST", Status: "active"}
+    s.Players = append(s.Players, Player{ID: "host", Name: "Host"})
+    for _, id := range []string{"a", "b", "c", "d"} {
+        s.Players = append(s.Players, Player{ID: id, Name: id})
+    }
+    if err := CreateTeam(s, "host", Team{ID: "t1", Name: "Reds"}); err != nil {
+        t.Fatal(err)
+    }
+    if err := CreateTeam(s, "host", Team{ID: "t2", Name: "Blacks"}); err != nil {
+        t.Fatal(err)
+    }
+    for pid, team := range map[string]string{"a": "t1", "b": "t1", "c": "t1", "d": "t2"} {
+        if err := AssignTeam(s, "host", pid, team, false); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := StartGame(s); err != nil {
+        t.Fatal(err)
+    }
+    s.Game.Shared = testShared
+
+    playRound(t, s, map[string]string{"a": "red", "b": "red", "c": "black", "d": "black"})
+    if got := s.Game.GiveOutRemainingByPlayer["c"]; got != 2 {
+        t.Errorf("expected c to share the team's win, got %d give-outs", got)
+    }
+    if got := drinksTaken(s, "d", s.GamesPlayed); got != 2 {
+        t.Errorf("expected d to drink 2, got %d", got)
+    }
+
+    s.Game.Started = false
+    s.Game.DistributionActive = true
+    if err := DistributeDrinks(s, "a", map[string]int{"b": 1}); err == nil {
+        t.Error("expected give-outs to teammates to be rejected")
+    }
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+    TournamentSeating = "seating"
+    TournamentFinal   = "final"
+
+    maxTournamentTables    = 16
+    defaultAdvancePerTable = 2
+
+    EventTournamentTable = "table_updated"
+    EventTournamentFinal = "final_table"
+)
+
+// Tournament links several lobbies ("tables") run by one host. Every table
+// shares the tournament's host ID, so the host controls them all.
+type Tournament struct {
+    Code            string    `json:"code"`
+    Name            string    `json:"name"`
+    HostID          string    `json:"hostId"`
+    Tables          []string  `json:"tables"`
+    AdvancePerTable int       `json:"advancePerTable"`
+    Entrants        []Entrant `json:"entrants"`
+    FinalTable      string    `json:"finalTable,omitempty"`
+    Status          string    `json:"status"` // seating | final
+    Locale          string    `json:"locale,omitempty"`
+    CreatedAt       time.Time `json:"createdAt"`
+}
+
+// Entrant is a seated player. FinalID is their player ID at the final table.
+type Entrant struct {
+    ID        string `json:"id"`
+    Name      string `json:"name"`
+    ProfileID string `json:"profileId,omitempty"`
+    Table     string `json:"table"`
+    FinalID   string `json:"finalId,omitempty"`
+}
+
+// Standing aggregates an entrant's results over every table they played.
+type Standing struct {
+    PlayerID string `json:"playerId"`
+    Name     string `json:"name"`
+    Table    string `json:"table"`
+    Survived int    `json:"survived"`
+    Drank    int    `json:"drank"`
+    Given    int    `json:"given"`
+    Finalist bool   `json:"finalist"`
+}
+
+func tournamentKey(code string) string { return "tournament:" + normalizeCode(code) }
+
+// tournamentChannel is both the pub/sub channel and the hub room of a
+// tournament's WS clients.
+func tournamentChannel(code string) string { return "tournament:" + normalizeCode(code) }
+
+func (s *RedisStore) CreateTournament(hostName, name, locale string, tables, advancePerTable int) (*Tournament, error) {
+    if tables < 1 || tables > maxTournamentTables {
+        return nil, codedErrorf("invalid_table_count", "tables must be between 1 and %d", maxTournamentTables)
+    }
+    if advancePerTable < 0 {
+        return nil, errNegativeAdvance
+    }
+    if advancePerTable == 0 {
+        advancePerTable = defaultAdvancePerTable
+    }
+    hostName = strings.TrimSpace(hostName)
+    if hostName == "" {
+        hostName = "Host"
+    }
+    host := Player{ID: newID("host_"), Name: hostName}
+
+    for i := 0; i < maxCodeGenerationAttempts; i++ {
+        code, err := generateLobbyCode(locale)
+        if err != nil {
+            return nil, err
+        }
+        t := &Tournament{
+            Code:            code,
+            Name:            strings.TrimSpace(name),
+            HostID:          host.ID,
+            AdvancePerTable: advancePerTable,
+            Entrants:        []Entrant{},
+            Status:          TournamentSeating,
+            Locale:          locale,
+            CreatedAt:       time.Now().UTC(),
+        }
+        b, _ := json.Marshal(t)
+        ok, err := s.rdb.SetNX(s.ctx, tournamentKey(code), b, s.ttl).Result()
+        if err != nil {
+            return nil, err
+        }
+        if !ok {
+            continue
+        }
+
+        for n := 0; n < tables; n++ {
+            session, err := s.createSession(host, code, locale)
+            if err != nil {
+                return nil, err
+            }
+            t.Tables = append(t.Tables, session.Code)
+        }
+        if err := s.saveTournament(t); err != nil {
+            return nil, err
+        }
+        return t, nil
+    }
+    return nil, errors.New("unable to generate unique tournament code")
+}
+
+func (s *RedisStore) GetTournament(code string) (*Tournament, bool) {
+    raw, err := s.rdb.Get(s.ctx, tournamentKey(code)).Result()
+    if err != nil {
+        return nil, false
+    }
+    var t Tournament
+    if err := json.Unmarshal([]byte(raw), &t); err != nil {
+        return nil, false
+    }
+    return &t, true
+}
+
+func (s *RedisStore) saveTournament(t *Tournament) error {
+    b, err := json.Marshal(t)
+    if err != nil {
+        return err
+    }
+    return s.rdb.Set(s.ctx, tournamentKey(t.Code), b, s.ttl).Err()
+}
+
+// JoinTournament seats a new player at the emptiest table.
+func (s *RedisStore) JoinTournament(code, name, profileToken string) (*Tournament, Entrant, error) {
+    profileID, err := s.profileForToken(profileToken)
+    if err != nil {
+        return nil, Entrant{}, err
+    }
+    t, ok := s.GetTournament(code)
+    if !ok {
+        return nil, Entrant{}, errTournamentNotFound
+    }
+    if t.Status != TournamentSeating {
+        return nil, Entrant{}, errSeatingClosed
+    }
+
+    seated := map[string]int{}
+    for _, e := range t.Entrants {
+        seated[e.Table]++
+    }
+    table := t.Tables[0]
+    for _, c := range t.Tables[1:] {
+        if seated[c] < seated[table] {
+            table = c
+        }
+    }
+
+    player, _, err := s.joinSession(table, name, profileID)
+    if err != nil {
+        return nil, Entrant{}, err
+    }
+    entrant := Entrant{ID: player.ID, Name: player.Name, ProfileID: player.ProfileID, Table: table}
+    t.Entrants = append(t.Entrants, entrant)
+    if err := s.saveTournament(t); err != nil {
+        return nil, Entrant{}, err
+    }
+    return t, entrant, nil
+}
+
+// Standings ranks entrants by rounds survived, then fewest drinks taken,
+// then most given out.
+func (s *RedisStore) Standings(t *Tournament) []Standing {
+    sessions := map[string]*Session{}
+    load := func(code string) *Session {
+        if code == "" {
+            return nil
+        }
+        if session, ok := sessions[code]; ok {
+            return session
+        }
+        session, _ := s.GetSession(code)
+        sessions[code] = session
+        return session
+    }
+
+    standings := make([]Standing, 0, len(t.Entrants))
+    for _, e := range t.Entrants {
+        st := Standing{PlayerID: e.ID, Name: e.Name, Table: e.Table, Finalist: e.FinalID != ""}
+        add := func(session *Session, playerID string) {
+            if session == nil || playerID == "" {
+                return
+            }
+            if p := findPlayer(session, playerID); p != nil {
+                st.Survived += p.RoundsSurvived
+            }
+            st.Drank += drinksTaken(session, playerID, 0)
+            st.Given += drinksGiven(session, playerID, 0)
+        }
+        add(load(e.Table), e.ID)
+        add(load(t.FinalTable), e.FinalID)
+        standings = append(standings, st)
+    }
+    sortStandings(standings)
+    return standings
+}
+
+func sortStandings(standings []Standing) {
+    sort.SliceStable(standings, func(i, j int) bool {
+        a, b := standings[i], standings[j]
+        if a.Survived != b.Survived {
+            return a.Survived > b.Survived
+        }
+        if a.Drank != b.Drank {
+            return a.Drank < b.Drank
+        }
+        if a.Given != b.Given {
+            return a.Given > b.Given
+        }
+        return a.Name < b.Name
+    })
+}
+
+// finalists picks the best AdvancePerTable entrants from every table.
+// Entrants who survived no round do not advance, so a table that never
+// played sends nobody.
+func finalists(t *Tournament, standings []Standing) []string {
+    perTable := map[string]int{}
+    var ids []string
+    for _, st := range standings {
+        if st.Survived <= 0 || perTable[st.Table] >= t.AdvancePerTable {
+            continue
+        }
+        perTable[st.Table]++
+        ids = append(ids, st.PlayerID)
+    }
+    return ids
+}
+
+// StartFinal opens the final table and seats the top survivors of each table.
+func (s *RedisStore) StartFinal(code, requesterID string) (*Tournament, error) {
+    t, ok := s.GetTournament(code)
+    if !ok {
+        return nil, errTournamentNotFound
+    }
+    if requesterID != t.HostID {
+        return nil, errHostOnlyFinal
+    }
+    if t.Status != TournamentSeating {
+        return nil, errFinalStarted
+    }
+    for _, table := range t.Tables {
+        if session, ok := s.GetSession(table); ok && (session.Game.Started || session.Game.DistributionActive) {
+            return nil, codedErrorf("table_playing", "table %s is still playing", table)
+        }
+    }
+
+    ids := finalists(t, s.Standings(t))
+    if len(ids) < 2 {
+        return nil, errNotEnoughFinalists
+    }
+
+    var host Player
+    if session, ok := s.GetSession(t.Tables[0]); ok {
+        if p := findPlayer(session, t.HostID); p != nil {
+            host = *p
+        }
+    }
+    if host.ID == "" {
+        host = Player{ID: t.HostID, Name: "Host"}
+    }
+    final, err := s.createSession(host, t.Code, t.Locale)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, id := range ids {
+        for i := range t.Entrants {
+            e := &t.Entrants[i]
+            if e.ID != id {
+                continue
+            }
+            player, _, err := s.joinSession(final.Code, e.Name, e.ProfileID)
+            if err != nil {
+                s.discardSession(final)
+                return nil, err
+            }
+            e.FinalID = player.ID
+        }
+    }
+    t.FinalTable = final.Code
+    t.Status = TournamentFinal
+    if err := s.saveTournament(t); err != nil {
+        s.discardSession(final)
+        return nil, err
+    }
+    return t, nil
+}
+
+// discardSession removes a lobby that never went live, such as a final
+// table whose seating failed, releasing its code and PIN.
+func (s *RedisStore) discardSession(session *Session) {
+    keys := []string{sessionKey(session.Code), eventsKey(session.Code), codeKey(session.Code)}
+    if session.PIN != "" {
+        keys = append(keys, pinKey(session.PIN))
+    }
+    if err := s.rdb.Del(s.ctx, keys...).Err(); err != nil {
+        log.Printf("lobby %s: discarding: %v", session.Code, err)
+    }
+}
+
+// publishTournament pushes a tournament message, through Redis when available.
+func publishTournament(ctx context.Context, code, msgType string, data any, bus *redisBus, hub *lobbyHub) {
+    payload, err := json.Marshal(map[string]any{"type": msgType, "data": data})
+    if err != nil {
+        return
+    }
+    if bus != nil {
+        _ = bus.PublishTournament(ctx, code, payload)
+        return
+    }
+    hub.broadcastRaw(tournamentChannel(code), payload)
+}
+
+func serveTournamentWS(w http.ResponseWriter, r *http.Request, store *RedisStore, hub *lobbyHub, code string) {
+    t, ok := store.GetTournament(code)
+    if !ok {
+        tournamentError(w, r, store, code, errTournamentNotFound, http.StatusNotFound)
+        return
+    }
+
+    conn, err := upgrader.Upgrade(w, r, nil)
+    if err != nil {
+        return
+    }
+
+    room := tournamentChannel(code)
+    hub.add(room, conn)
+    defer func() {
+        hub.remove(room, conn)
+        _ = conn.Close()
+    }()
+
+    payload, err := json.Marshal(map[string]any{
+        "type": "tournament",
+        "data": map[string]any{"tournament": t, "standings": store.Standings(t)},
+    })
+    if err == nil {
+        _ = conn.WriteMessage(websocket.TextMessage, payload)
+    }
+
+    for {
+        if _, _, err := conn.ReadMessage(); err != nil {
+            return
+        }
+    }
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFinalistsTakesTopSurvivorsPerTable(t *testing.T) {
+    tr := &Tournament{Tables: []string{"A", "B"}, AdvancePerTable: 1}
+    standings := []Standing{
+        {PlayerID: "a1", Table: "A", Survived: 2, Drank: 5},
+        {PlayerID: "a2", Table: "A", Survived: 4, Drank: 9},
+        {PlayerID: "b1", Table: "B", Survived: 1, Drank: 1},
+        {PlayerID: "b2", Table: "B", Survived: 1, Drank: 3},
+    }
+    sortStandings(standings)
+
+    got := finalists(tr, standings)
+    if len(got) != 2 || got[0] != "a2" || got[1] != "b1" {
+        t.Errorf("expected [a2 b1], got %v", got)
+    }
+}
+
+// seatTournament creates a two-table tournament, seats the given players
+// and records how many rounds each survived at their table.
+func seatTournament(t *testing.T, store *RedisStore, survived map[string]int, names ...string) (*Tournament, map[string]Entrant) {
+    t.Helper()
+    tr, err := store.CreateTournament("Host", "Cup", "", 2, 2)
+    if err != nil {
+        t.Fatal(err)
+    }
+    entrants := map[string]Entrant{}
+    for _, name := range names {
+        _, e, err := store.JoinTournament(tr.Code, name, "")
+        if err != nil {
+            t.Fatal(err)
+        }
+        entrants[name] = e
+    }
+    for name, n := range survived {
+        e := entrants[name]
+        session, ok := store.GetSession(e.Table)
+        if !ok {
+            t.Fatalf("table %s not found", e.Table)
+        }
+        findPlayer(session, e.ID).RoundsSurvived = n
+        if err := store.writeSnapshot(session, store.ttl); err != nil {
+            t.Fatal(err)
+        }
+    }
+    tr, _ = store.GetTournament(tr.Code)
+    return tr, entrants
+}
+
+func TestStandingsRankAcrossTables(t *testing.T) {
+    store, _ := newTestStore(t)
+    tr, entrants := seatTournament(t, store, map[string]int{"Ann": 2, "Cid": 1, "Dee": 3}, "Ann", "Bob", "Cid", "Dee")
+    if entrants["Ann"].Table == entrants["Bob"].Table {
+        t.Fatal("expected players to be spread over both tables")
+    }
+
+    var got []string
+    for _, st := range store.Standings(tr) {
+        got = append(got, st.Name)
+    }
+    if want := "Dee Ann Cid Bob"; strings.Join(got, " ") != want {
+        t.Errorf("expected standings %s, got %v", want, got)
+    }
+}
+
+func TestStartFinalSeatsSurvivors(t *testing.T) {
+    store, _ := newTestStore(t)
+    tr, entrants := seatTournament(t, store, map[string]int{"Ann": 2, "Cid": 1, "Dee": 3}, "Ann", "Bob", "Cid", "Dee")
+
+    tr, err := store.StartFinal(tr.Code, tr.HostID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if tr.Status != TournamentFinal {
+        t.Errorf("expected the final to be running, got %s", tr.Status)
+    }
+    final, ok := store.GetSession(tr.FinalTable)
+    if !ok {
+        t.Fatal("final table not found")
+    }
+    var seated []string
+    for _, p := range final.Players {
+        if p.ID != tr.HostID {
+            seated = append(seated, p.Name)
+        }
+    }
+    sort.Strings(seated)
+    if want := "Ann Cid Dee"; strings.Join(seated, " ") != want {
+        t.Errorf("expected %s at the final, got %v", want, seated)
+    }
+    for _, e := range tr.Entrants {
+        if (e.FinalID != "") != (e.ID != entrants["Bob"].ID) {
+            t.Errorf("unexpected final seat for %s: %q", e.Name, e.FinalID)
+        }
+    }
+}
+
+func TestStartFinalDiscardsTableWhenSavingFails(t *testing.T) {
+    store, fr := newTestStore(t)
+    tr, _ := seatTournament(t, store, map[string]int{"Ann": 1, "Bob": 1}, "Ann", "Bob")
+    countSessions := func() int {
+        fr.mu.Lock()
+        defer fr.mu.Unlock()
+        n := 0
+        for key := range fr.strings {
+            if strings.HasPrefix(key, "session:") {
+                n++
+            }
+        }
+        return n
+    }
+    before := countSessions()
+
+    fr.failSetsOn("tournament:")
+    if _, err := store.StartFinal(tr.Code, tr.HostID); err == nil {
+        t.Fatal("expected the final to fail")
+    }
+    if got := countSessions(); got != before {
+        t.Errorf("expected the half-seated final to be discarded, got %d sessions, want %d", got, before)
+    }
+    if got, _ := store.GetTournament(tr.Code); got.Status != TournamentSeating || got.FinalTable != "" {
+        t.Errorf("expected the tournament to stay in seating, got %+v", got)
+    }
+}
+
+func TestTablePlayKeepsTheTournamentAlive(t *testing.T) {
+    store, fr := newTestStore(t)
+    tr, err := store.CreateTournament("Host", "Cup", "", 1, 2)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // Seating ends early; the table keeps playing well past the TTL.
+    for i := 0; i < 3; i++ {
+        fr.advance(store.ttl - time.Minute)
+        if _, _, err := store.JoinSession(tr.Tables[0], "Guest", ""); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if _, ok := store.GetTournament(tr.Code); !ok {
+        t.Fatal("expected table activity to keep the tournament")
+    }
+
+    fr.advance(store.ttl + time.Minute)
+    if _, ok := store.GetTournament(tr.Code); ok {
+        t.Error("expected the tournament to expire once its tables went quiet")
+    }
+}
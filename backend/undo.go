@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// UndoWindow is how long after a transition the host may still revert it.
+const UndoWindow = 30 * time.Second
+
+// EventUndoApplied tells clients a transition was reverted.
+const EventUndoApplied = "undo_applied"
+
+// UndoPoint is the state right before the last undoable transition. It is
+// part of the persisted session, so it survives snapshots and replays.
+type UndoPoint struct {
+    Action      string        `json:"action"`
+    At          time.Time     `json:"at"`
+    GamesPlayed int           `json:"gamesPlayed"`
+    Game        GameState     `json:"game"`
+    Players     []Player      `json:"players"`
+    Ledger      []LedgerEntry `json:"ledger"`
+}
+
+// undoableEvents are the transitions that settle drinks; guesses and joins
+// are cheap to redo and are not tracked.
+var undoableEvents = map[string]bool{
+    SessionEventRoundAdvanced:         true,
+    SessionEventDrinksDistributed:     true,
+    SessionEventDistributionFinalized: true,
+}
+
+func captureUndoPoint(s *Session, action string) (*UndoPoint, error) {
+    b, err := json.Marshal(struct {
+        Game    GameState     `json:"game"`
+        Players []Player      `json:"players"`
+        Ledger  []LedgerEntry `json:"ledger"`
+    }{s.Game, s.Players, s.Ledger})
+    if err != nil {
+        return nil, err
+    }
+    point := &UndoPoint{Action: action, At: s.now(), GamesPlayed: s.GamesPlayed}
+    if err := json.Unmarshal(b, point); err != nil {
+        return nil, err
+    }
+    return point, nil
+}
+
+// UndoLast restores the state saved before the last undoable transition.
+// Timers restart from now, since the original deadline has usually passed.
+func UndoLast(s *Session, requesterID string) error {
+    if s == nil {
+        return errors.New("session required")
+    }
+    if requesterID != s.HostID {
+        return errHostOnlyUndo
+    }
+    // A point left over from an earlier game would rewind into it.
+    if s.Undo == nil || s.Undo.GamesPlayed != s.GamesPlayed {
+        return errNothingToUndo
+    }
+    if s.now().Sub(s.Undo.At) > UndoWindow {
+        return errUndoExpired
+    }
+
+    point := s.Undo
+    wasFinished := gameFinished(s)
+    badgesBefore := map[string][]string{}
+    for _, p := range s.Players {
+        badgesBefore[p.ID] = p.Badges
+    }
+
+    s.Game = point.Game
+    restorePlayerTotals(s, point.Players)
+    s.Ledger = point.Ledger
+    s.Undo = nil
+
+    now := s.now()
+    if s.Game.Started {
+        deadline := now.Add(RoundDuration)
+        s.Game.Deadline = &deadline
+    }
+    if s.Game.DistributionActive {
+        deadline := now.Add(DistributionDuration)
+        s.Game.DistributionDeadline = &deadline
+    }
+
+    s.logHistory(HistoryEntry{Type: HistoryUndo, Round: s.Game.Round, PlayerID: requesterID, Action: point.Action})
+    if wasFinished && !gameFinished(s) {
+        s.logHistory(HistoryEntry{Type: HistoryGameReopened, Round: s.Game.Round})
+    }
+    revokeLostBadges(s, badgesBefore)
+    s.emit(EventUndoApplied, map[string]any{
+        "action": point.Action,
+        "round":  s.Game.Round,
+    })
+    return nil
+}
+
+// restorePlayerTotals rolls back what a transition changes on players: their
+// totals, counters and badges. Names, profiles and drink profiles stay as
+// they are now, and players who joined after the undo point are kept.
+func restorePlayerTotals(s *Session, saved []Player) {
+    before := map[string]Player{}
+    for _, p := range saved {
+        before[p.ID] = p
+    }
+    for i := range s.Players {
+        old, ok := before[s.Players[i].ID]
+        if !ok {
+            continue
+        }
+        p := &s.Players[i]
+        p.Score, p.LifetimeDrank, p.GivenOut = old.Score, old.LifetimeDrank, old.GivenOut
+        p.Badges, p.SuitStreak, p.RoundsSurvived = old.Badges, old.SuitStreak, old.RoundsSurvived
+        p.BACWarned = old.BACWarned
+        p.PerfectRuns, p.LastPerfectGame = old.PerfectRuns, old.LastPerfectGame
+    }
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUndoRestoresAdvancedRound(t *testing.T) {
+    s := newTestSession("a")
+    now := time.Now().UTC()
+
+    if err := applyEvent(s, SessionEvent{Type: SessionEventRoundAdvanced, At: now}); err != nil {
+        t.Fatal(err)
+    }
+    if s.Game.DrinkNowByPlayer["a"] != 2 || len(s.Game.ActivePlayers) != 0 {
+        t.Fatalf("expected a to drink and be eliminated, got %+v", s.Game)
+    }
+
+    if err := applyEvent(s, SessionEvent{Type: SessionEventUndone, At: now, PlayerID: "a"}); err == nil {
+        t.Fatal("expected non-host undo to fail")
+    }
+    if err := applyEvent(s, SessionEvent{Type: SessionEventUndone, At: now.Add(time.Second), PlayerID: "host"}); err != nil {
+        t.Fatal(err)
+    }
+
+    if s.Game.Round != 0 || !s.Game.Started || s.Game.DrinkNowByPlayer["a"] != 0 {
+        t.Errorf("round not restored: %+v", s.Game)
+    }
+    if p := findPlayer(s, "a"); p.LifetimeDrank != 0 {
+        t.Errorf("player totals not restored: %+v", p)
+    }
+}
+
+func TestUndoingGameEndRevertsProfileStats(t *testing.T) {
+    store, _ := newTestStore(t)
+    profile, token, err := store.CreateProfile("Ada", false)
+    if err != nil {
+        t.Fatal(err)
+    }
+    created, host, err := store.CreateSession("Host", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    code := created.Code
+    player, _, err := store.JoinSession(code, "Ada", token)
+    if err != nil {
+        t.Fatal(err)
+    }
+    live, err := store.StartSession(code)
+    if err != nil {
+        t.Fatal(err)
+    }
+    wrong := "red"
+    if c := live.Game.Shared[0]; c.Suit == Hearts || c.Suit == Diamonds {
+        wrong = "black"
+    }
+    if _, err := store.SubmitGuess(code, player.ID, wrong); err != nil {
+        t.Fatal(err)
+    }
+
+    drank := func() (int, int) {
+        t.Helper()
+        p, _ := store.GetProfile(profile.ID)
+        board, err := store.Leaderboard(WindowAllTime, "", MetricDrank, 10)
+        if err != nil {
+            t.Fatal(err)
+        }
+        score := 0
+        if len(board) > 0 {
+            score = board[0].Score
+        }
+        return p.TotalDrank, score
+    }
+
+    // Losing the only player's first round ends the game.
+    if _, err := store.AdvanceRound(code); err != nil {
+        t.Fatal(err)
+    }
+    if total, score := drank(); total != 2 || score != 2 {
+        t.Fatalf("expected the finished game recorded, got %d drank and score %d", total, score)
+    }
+
+    undone, err := store.Undo(code, host.ID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if total, score := drank(); total != 0 || score != 0 {
+        t.Errorf("expected the undo to take the game back out, got %d drank and score %d", total, score)
+    }
+    if p, _ := store.GetProfile(profile.ID); p.GamesPlayed != 0 || p.RoundsPlayed[0] != 0 {
+        t.Errorf("expected games and rounds reverted, got %+v", p)
+    }
+    history, err := store.History(code, undone.GamesPlayed)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, e := range history {
+        if e.Type == HistoryGameFinished {
+            t.Errorf("expected the undone game_finished to be dropped, got %+v", history)
+        }
+    }
+
+    // The corrected result is recorded when the game ends again.
+    if _, err := store.AdvanceRound(code); err != nil {
+        t.Fatal(err)
+    }
+    if total, score := drank(); total != 2 || score != 2 {
+        t.Errorf("expected the game recorded once more, got %d drank and score %d", total, score)
+    }
+}
+
+func TestUndoDoesNotReachIntoThePreviousGame(t *testing.T) {
+    s := newTestSession("a")
+    now := time.Now().UTC()
+
+    if err := applyEvent(s, SessionEvent{Type: SessionEventRoundAdvanced, At: now}); err != nil {
+        t.Fatal(err)
+    }
+    if s.Game.Started || s.Game.DistributionActive {
+        t.Fatalf("expected the game to be over, got %+v", s.Game)
+    }
+    cards := []Card{{2, Hearts}, {3, Clubs}, {4, Spades}, {5, Diamonds}}
+    if err := applyEvent(s, SessionEvent{Type: SessionEventGameStarted, At: now.Add(time.Second), Cards: cards}); err != nil {
+        t.Fatal(err)
+    }
+    games := s.GamesPlayed
+
+    if err := applyEvent(s, SessionEvent{Type: SessionEventUndone, At: now.Add(2 * time.Second), PlayerID: "host"}); err == nil {
+        t.Fatal("expected undo right after a new start to fail")
+    }
+    if s.GamesPlayed != games || !s.Game.Started || s.Game.Round != 0 {
+        t.Errorf("expected the new game untouched, got %d games and %+v", s.GamesPlayed, s.Game)
+    }
+
+    // A point captured in another game is refused even if it is still set.
+    s.Undo = &UndoPoint{Action: SessionEventRoundAdvanced, At: now, GamesPlayed: games - 1}
+    if err := UndoLast(s, "host"); err == nil {
+        t.Error("expected an undo point from an earlier game to be refused")
+    }
+}
+
+func TestUndoKeepsPlayersWhoJoinedLater(t *testing.T) {
+    s := newTestSession("a", "b")
+    now := time.Now().UTC()
+
+    guessAll(t, s, map[string]string{"a": "red", "b": "black"})
+    if err := applyEvent(s, SessionEvent{Type: SessionEventRoundAdvanced, At: now}); err != nil {
+        t.Fatal(err)
+    }
+    late := Player{ID: "c", Name: "c"}
+    if err := applyEvent(s, SessionEvent{Type: SessionEventPlayerJoined, At: now, Player: &late}); err != nil {
+        t.Fatal(err)
+    }
+    findPlayer(s, "a").Name = "Ann"
+
+    if err := applyEvent(s, SessionEvent{Type: SessionEventUndone, At: now.Add(time.Second), PlayerID: "host"}); err != nil {
+        t.Fatal(err)
+    }
+    if findPlayer(s, "c") == nil {
+        t.Error("expected the player who joined after the advance to stay")
+    }
+    if p := findPlayer(s, "a"); p.Name != "Ann" || p.RoundsSurvived != 0 {
+        t.Errorf("expected a's name kept and round undone, got %+v", p)
+    }
+    if p := findPlayer(s, "b"); p.LifetimeDrank != 0 {
+        t.Errorf("expected b's drinks undone, got %+v", p)
+    }
+}
@@ -1,4 +1,5 @@
 import useLobbySocket, {
+  formatUnits,
   mapSessionToViewState,
 } from "@components/useLobbySocket";
 import { useCallback, useEffect, useRef, useState } from "react";
@@ -66,6 +67,7 @@ const HostView = ({ lobbyId }) => {
   const mockIntervalRef = useRef(null);
   const [startingGame, setStartingGame] = useState(false);
   const [restartingGame, setRestartingGame] = useState(false);
+  const [notices, setNotices] = useState([]);
   const { formattedTime, isExpired } = useCountdown(gameState?.deadline);
   const showJoinQr = !gameState || gameState.phase === "waiting";
 
@@ -88,17 +90,75 @@ const HostView = ({ lobbyId }) => {
     }
   };
 
+  const nameByIdRef = useRef({});
+
   const handleSession = useCallback((session) => {
     stopMockCycle();
-    setGameState(mapSessionToViewState(session));
+    const view = mapSessionToViewState(session);
+    nameByIdRef.current = view.nameById;
+    setGameState(view);
     setUsingMock(false);
     setError("");
     setLoading(false);
   }, []);
 
+  const handleEvent = useCallback((type, data, unit) => {
+    let notice = null;
+    if (type === "achievement_unlocked") {
+      notice = {
+        title: "🏅 Achievement unlocked",
+        headline: `${data.playerName}: ${data.name}`,
+        detail: data.description,
+      };
+    } else if (type === "safety_cap_reached") {
+      notice = {
+        title: "🛑 Drink cap reached",
+        headline: data.playerName,
+        detail:
+          data.action === "tap_out"
+            ? "Tapped out automatically."
+            : data.action === "redirect"
+              ? `${formatUnits(data.dropped, unit)} redirected to others.`
+              : `${formatUnits(data.dropped, unit)} dropped.`,
+      };
+    } else if (type === "roulette_reveal") {
+      notice = {
+        title: "🎰 Roulette",
+        headline: nameByIdRef.current[data.targetId] || "Someone",
+        detail: `takes ${formatUnits(data.amount, unit)} from ${nameByIdRef.current[data.giverId] || "a giver"}`,
+      };
+    } else if (type === "challenge_drawn") {
+      notice = {
+        title: "🃏 Challenge",
+        headline: data.playerName,
+        detail: data.challenge,
+      };
+    } else if (type === "bac_warning") {
+      notice = {
+        title: "⚠️ Take it easy",
+        headline: data.playerName,
+        detail: `Estimated BAC ${Number(data.estimatedBac).toFixed(2)}%`,
+      };
+    } else if (type === "revenge") {
+      notice = {
+        title: "😈 Revenge",
+        headline: nameByIdRef.current[data.giverId] || "Someone",
+        detail: `hits back at ${nameByIdRef.current[data.targetId] || "their giver"} (+${formatUnits(data.bonus, unit)})`,
+      };
+    }
+    if (!notice) return;
+
+    const key = `${type}:${data.playerId}:${Date.now()}`;
+    setNotices((prev) => [...prev, { ...notice, key }]);
+    setTimeout(() => {
+      setNotices((prev) => prev.filter((a) => a.key !== key));
+    }, 6000);
+  }, []);
+
   const { connected } = useLobbySocket({
     lobbyId,
     onSession: handleSession,
+    onEvent: handleEvent,
   });
 
   useEffect(() => {
@@ -147,6 +207,27 @@ const HostView = ({ lobbyId }) => {
     }
   };
 
+  const postHostAction = async (action, extra = {}) => {
+    setError("");
+
+    try {
+      const response = await fetch(`/api/lobbies/${lobbyId}/${action}`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({
+          hostId: localStorage.getItem(`hostId:${lobbyId}`) || "",
+          ...extra,
+        }),
+      });
+
+      if (!response.ok) {
+        throw new Error((await response.text()) || `Failed to ${action}`);
+      }
+    } catch (err) {
+      setError(err.message || `Failed to ${action}`);
+    }
+  };
+
   const handleRestartGame = async () => {
     setRestartingGame(true);
     setError("");
@@ -195,7 +276,13 @@ const HostView = ({ lobbyId }) => {
         </div>
       )}
 
-      {showJoinQr && <JoinQrCard lobbyId={lobbyId} />}
+      {showJoinQr && (
+        <JoinQrCard
+          lobbyId={lobbyId}
+          pin={gameState?.pin}
+          playerCount={gameState?.players?.length || 0}
+        />
+      )}
 
       <div className="text-center mb-8">
         <h1 className="text-4xl font-bold text-white mb-2">Ride the Bus</h1>
@@ -207,8 +294,58 @@ const HostView = ({ lobbyId }) => {
           <span>Players: {gameState?.players?.length || 0}</span>
         </div>
         {error && <p className="text-yellow-500 text-sm mt-2">{error}</p>}
+        <div className="mt-3 flex justify-center gap-3">
+          {(gameState?.paused ||
+            gameState?.deadline ||
+            gameState?.distributionDeadline) && (
+            <button
+              onClick={() =>
+                postHostAction(gameState?.paused ? "resume" : "pause")
+              }
+              className="rounded-lg bg-gray-700 px-4 py-2 text-sm font-semibold text-white hover:bg-gray-600"
+            >
+              {gameState?.paused ? "▶️ Resume" : "⏸️ Pause"}
+            </button>
+          )}
+          {gameState?.undo && (
+            <button
+              onClick={() => postHostAction("undo")}
+              className="rounded-lg bg-gray-700 px-4 py-2 text-sm font-semibold text-white hover:bg-gray-600"
+            >
+              ↩️ Undo last action
+            </button>
+          )}
+          {gameState?.phase === "waiting" && (
+            <button
+              onClick={() => postHostAction("bots", { strategy: "optimal" })}
+              className="rounded-lg bg-gray-700 px-4 py-2 text-sm font-semibold text-white hover:bg-gray-600"
+            >
+              🤖 Add bot
+            </button>
+          )}
+        </div>
+        {gameState?.paused && (
+          <p className="mt-2 text-2xl font-bold text-yellow-400">⏸️ Paused</p>
+        )}
       </div>
 
+      {notices.length > 0 && (
+        <div className="fixed top-6 right-6 z-50 space-y-3">
+          {notices.map((a) => (
+            <div
+              key={a.key}
+              className="rounded-xl border border-yellow-300 bg-yellow-100 px-5 py-3 shadow-2xl"
+            >
+              <p className="text-sm font-semibold text-yellow-800">
+                {a.title}
+              </p>
+              <p className="text-lg font-bold text-gray-900">{a.headline}</p>
+              <p className="text-sm text-gray-700">{a.detail}</p>
+            </div>
+          ))}
+        </div>
+      )}
+
       {/* Scale down cards during the result phase */}
       <div
         className={`mx-auto transition-all duration-500 ${gameState?.phase === "result" ? "max-w-md scale-75 origin-top mb-8" : "max-w-4xl"}`}
@@ -280,6 +417,12 @@ const HostView = ({ lobbyId }) => {
                         <span className="text-white text-2xl font-bold">
                           {p.nickname}
                         </span>
+                        {p.teamName && (
+                          <span className="text-sm text-gray-300 bg-gray-600 rounded px-2 py-1">
+                            {p.isCaptain ? "★ " : ""}
+                            {p.teamName}
+                          </span>
+                        )}
                       </div>
                       <span className="text-gray-200 text-lg">
                         Drink:{" "}
@@ -294,6 +437,13 @@ const HostView = ({ lobbyId }) => {
                         <strong className="text-red-400">
                           {p.lifetimeDrank ?? p.score ?? 0}
                         </strong>
+                        {p.owed > 0 && (
+                          <>
+                            {" "}
+                            • Still owes:{" "}
+                            <strong className="text-yellow-300">{p.owed}</strong>
+                          </>
+                        )}
                       </span>
                     </div>
                   ))}
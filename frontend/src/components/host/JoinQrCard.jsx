@@ -1,11 +1,12 @@
 import { useEffect, useState } from "react";
 import QRCode from "qrcode";
 
-const JoinQrCard = ({ lobbyId }) => {
+const JoinQrCard = ({ lobbyId, pin, playerCount = 0 }) => {
   const [joinUrl, setJoinUrl] = useState("");
   const [qrDataUrl, setQrDataUrl] = useState("");
   const [copied, setCopied] = useState(false);
 
+  // Join links are single-use, so fetch a fresh one whenever someone joins.
   useEffect(() => {
     let alive = true;
 
@@ -15,25 +16,46 @@ const JoinQrCard = ({ lobbyId }) => {
       return () => {};
     }
 
-    const url = `${window.location.origin}/play/${lobbyId}`;
-    setJoinUrl(url);
+    const fallbackUrl = `${window.location.origin}/play/${lobbyId}`;
 
-    QRCode.toDataURL(url, {
-      width: 220,
-      margin: 1,
-      errorCorrectionLevel: "M",
-    })
-      .then((dataUrl) => {
-        if (alive) setQrDataUrl(dataUrl);
+    const loadJoinUrl = async () => {
+      try {
+        const response = await fetch(`/api/lobbies/${lobbyId}/join-link`, {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify({
+            hostId: localStorage.getItem(`hostId:${lobbyId}`) || "",
+          }),
+        });
+        if (!response.ok) return fallbackUrl;
+        const data = await response.json();
+        return data?.path ? `${window.location.origin}${data.path}` : fallbackUrl;
+      } catch {
+        return fallbackUrl;
+      }
+    };
+
+    loadJoinUrl().then((url) => {
+      if (!alive) return;
+      setJoinUrl(url);
+
+      QRCode.toDataURL(url, {
+        width: 220,
+        margin: 1,
+        errorCorrectionLevel: "M",
       })
-      .catch(() => {
-        if (alive) setQrDataUrl("");
-      });
+        .then((dataUrl) => {
+          if (alive) setQrDataUrl(dataUrl);
+        })
+        .catch(() => {
+          if (alive) setQrDataUrl("");
+        });
+    });
 
     return () => {
       alive = false;
     };
-  }, [lobbyId]);
+  }, [lobbyId, playerCount]);
 
   if (!lobbyId) return null;
 
@@ -67,6 +89,7 @@ const JoinQrCard = ({ lobbyId }) => {
       <p className="mt-3 text-sm text-gray-600 break-all">{joinUrl || "..."}</p>
       <p className="mt-1 text-xs text-gray-500">
         Code: {String(lobbyId).toUpperCase()}
+        {pin ? ` · PIN: ${pin}` : ""}
       </p>
 
       <button
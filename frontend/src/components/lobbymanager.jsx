@@ -29,6 +29,9 @@ const LobbyManager = () => {
       const code = data?.session?.code;
 
       localStorage.setItem("playerNickname", "Host");
+      if (data?.hostId) {
+        localStorage.setItem(`hostId:${code}`, data.hostId);
+      }
 
       window.location.href = `/host/${code}`;
 
@@ -70,14 +73,16 @@ const LobbyManager = () => {
       }
 
       const data = await response.json();
+      // A PIN resolves to the lobby's word code; key everything by that.
+      const lobbyKey = data?.session?.code || code;
 
       // store exact keys expected by PlayerView
-      localStorage.setItem(`playerNickname:${code}`, hostName.trim());
+      localStorage.setItem(`playerNickname:${lobbyKey}`, hostName.trim());
       if (data?.playerId) {
-        localStorage.setItem(`playerId:${code}`, data.playerId);
+        localStorage.setItem(`playerId:${lobbyKey}`, data.playerId);
       }
 
-      window.location.href = `/play/${code}`;
+      window.location.href = `/play/${lobbyKey}`;
     } catch (err) {
       setError(err.message || "Failed to join lobby");
     } finally {
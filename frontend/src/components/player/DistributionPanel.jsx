@@ -15,7 +15,12 @@ const DistributionPanel = ({
     [allocations],
   );
   const leftToAllocate = Math.max(0, giveOutRemaining - totalAllocated);
-  const targets = players.filter((p) => p.id !== meId && !p.isSpectator);
+  const myTeamId = players.find((p) => p.id === meId)?.teamId ?? null;
+  // Give-outs only go to other teams in team mode.
+  const targets = players.filter(
+    (p) =>
+      p.id !== meId && !p.isSpectator && (!myTeamId || p.teamId !== myTeamId),
+  );
 
   return (
     <div className="space-y-3">
@@ -0,0 +1,66 @@
+import { useState } from "react";
+import { formatUnits } from "../useLobbySocket";
+
+const reasonLabels = {
+  lost_round: "Lost the round",
+  given: "Given by",
+  leftover: "Leftover from",
+};
+
+const DrinkQueue = ({ lobbyId, playerId, me, unit, nameById, usingMock }) => {
+  const [submitting, setSubmitting] = useState(false);
+  const [error, setError] = useState("");
+
+  const pending = me?.pendingDrinks || [];
+  if (!me || pending.length === 0) return null;
+
+  const onAck = async () => {
+    if (submitting || !playerId) return;
+    setSubmitting(true);
+    setError("");
+
+    if (usingMock) {
+      setSubmitting(false);
+      return;
+    }
+
+    try {
+      const res = await fetch(`/api/lobbies/${lobbyId}/ack`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ playerId }),
+      });
+      if (!res.ok) throw new Error((await res.text()) || "Failed to confirm");
+    } catch (e) {
+      setError(e.message || "Failed to confirm");
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  return (
+    <div className="mt-3 p-3 rounded-lg border border-orange-300 bg-orange-50">
+      <p className="font-bold text-orange-700 mb-2">
+        You owe {formatUnits(me.owed, unit)}
+      </p>
+      <ul className="text-sm text-gray-700 space-y-1 mb-3">
+        {pending.map((d) => (
+          <li key={d.id}>
+            {d.amount} × {reasonLabels[d.reason] || d.reason}
+            {d.fromId ? ` ${nameById[d.fromId] || "someone"}` : ""}
+          </li>
+        ))}
+      </ul>
+      <button
+        onClick={onAck}
+        disabled={submitting}
+        className="w-full py-2 rounded-lg font-medium bg-orange-600 text-white disabled:opacity-50"
+      >
+        {unit?.alcohol === false ? "Done it ✅" : "I drank it 🍺"}
+      </button>
+      {error && <p className="text-sm text-red-600 mt-2">{error}</p>}
+    </div>
+  );
+};
+
+export default DrinkQueue;
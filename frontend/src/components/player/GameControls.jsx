@@ -62,6 +62,24 @@ const GameControls = ({ gameState, lobbyId, nickname, playerId, usingMock }) =>
     }
   };
 
+  const letItRide = async () => {
+    if (usingMock || !me?.id) return;
+    setError("");
+    try {
+      const response = await fetch(`/api/lobbies/${lobbyId}/ride`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ playerId: me.id }),
+      });
+      if (!response.ok) {
+        const txt = await response.text();
+        throw new Error(txt || "Failed to let it ride");
+      }
+    } catch (err) {
+      setError(err.message || "Failed to let it ride");
+    }
+  };
+
   const submitDistribution = async () => {
     if (leftToAllocate !== 0 || submitting) return;
     setSubmitting(true);
@@ -113,7 +131,22 @@ const GameControls = ({ gameState, lobbyId, nickname, playerId, usingMock }) =>
     });
   };
 
+  const customRound = gameState.customRounds?.[gameState.round - 1] ?? null;
+  const customColors = [
+    "bg-blue-600 hover:bg-blue-700",
+    "bg-purple-600 hover:bg-purple-700",
+    "bg-green-600 hover:bg-green-700",
+    "bg-yellow-600 hover:bg-yellow-700",
+  ];
+
   const getChoicesForPhase = () => {
+    if (customRound && gameState.phase !== "result") {
+      return customRound.options.map((o, i) => ({
+        value: o.value.trim().toLowerCase(),
+        label: (o.label || o.value).toUpperCase(),
+        color: customColors[i % customColors.length],
+      }));
+    }
     switch (gameState.phase) {
       case "red_black":
         return [
@@ -188,7 +221,43 @@ const GameControls = ({ gameState, lobbyId, nickname, playerId, usingMock }) =>
     );
   }
 
-  // 4. Player is Eliminated (Spectating)
+  // 4a. Tapped out with cash-out: hand out give-outs right away
+  if (
+    me?.status === "tapped_out" &&
+    gameState.tapOut?.cashOut &&
+    giveOutRemaining > 0
+  ) {
+    return (
+      <div className="bg-white rounded-lg shadow-md p-4">
+        <p className="font-medium text-center text-gray-700 mb-3">
+          💸 You tapped out. Cash out your give-outs now:
+        </p>
+        <DistributionPanel
+          players={gameState.players || []}
+          meId={me?.id}
+          giveOutRemaining={giveOutRemaining}
+          allocations={allocations}
+          onInc={inc}
+          onDec={dec}
+          onSubmit={submitDistribution}
+          submitting={submitting}
+        />
+        {error && <p className="text-sm text-red-600 text-center mt-3">⚠️ {error}</p>}
+      </div>
+    );
+  }
+
+  // 4. Player is out (Spectating)
+  if (me?.isSpectator && me?.status === "tapped_out") {
+    return (
+      <div className="bg-white rounded-lg shadow-md p-6">
+        <div className="text-center">
+          <p className="text-xl font-bold text-gray-700 mb-2">🚪 You tapped out</p>
+          <p className="text-gray-600">You are now spectating. Wait for the next game.</p>
+        </div>
+      </div>
+    );
+  }
   if (me?.isSpectator) {
     return (
       <div className="bg-white rounded-lg shadow-md p-6">
@@ -217,12 +286,13 @@ const GameControls = ({ gameState, lobbyId, nickname, playerId, usingMock }) =>
     <div className="bg-white rounded-lg shadow-md p-4">
       <div className="space-y-3">
         <p className="font-medium text-center text-gray-700">
-          {gameState.phase === "red_black" && "Choose Red or Black:"}
-          {gameState.phase === "higher_lower" &&
+          {customRound && `${customRound.name}:`}
+          {!customRound && gameState.phase === "red_black" && "Choose Red or Black:"}
+          {!customRound && gameState.phase === "higher_lower" &&
             "Will the next card be Higher or Lower?"}
-          {gameState.phase === "between_outside" &&
+          {!customRound && gameState.phase === "between_outside" &&
             "Will the next card be Between or Outside?"}
-          {gameState.phase === "suit" && "Choose your suit:"}
+          {!customRound && gameState.phase === "suit" && "Choose your suit:"}
         </p>
 
         <div className="grid gap-3 grid-cols-2">
@@ -234,11 +304,31 @@ const GameControls = ({ gameState, lobbyId, nickname, playerId, usingMock }) =>
               className={`${choice.color} text-white rounded-lg font-bold py-4 px-2 transition-all transform hover:scale-105 disabled:opacity-50 disabled:cursor-not-allowed disabled:hover:scale-100 ${selected === choice.value ? "ring-4 ring-yellow-400 scale-105" : ""}`}
             >
               {choice.label}
+              {gameState.odds?.[choice.value] !== undefined && (
+                <span className="block text-xs font-medium opacity-80">
+                  {Math.round(gameState.odds[choice.value] * 100)}%
+                </span>
+              )}
             </button>
           ))}
         </div>
       </div>
 
+      {giveOutRemaining > 0 && (
+        <button
+          onClick={letItRide}
+          disabled={submitting || selected}
+          className="w-full mt-3 py-2 rounded-lg font-medium bg-amber-500 text-white disabled:opacity-50"
+        >
+          🎲 Let it ride ({giveOutRemaining} → {giveOutRemaining * 2} or nothing)
+        </button>
+      )}
+      {me?.riding > 0 && (
+        <p className="text-sm text-amber-600 font-medium text-center mt-3">
+          Riding {me.riding} on this guess
+        </p>
+      )}
+
       {isTapOutPending && !hasGuessed && (
         <p className="text-sm text-orange-600 font-medium text-center mt-3">
           Tap out requested! Make your choice for this final round.
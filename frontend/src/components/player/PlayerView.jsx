@@ -1,9 +1,11 @@
 import useLobbySocket, {
+  formatUnits,
   mapSessionToViewState,
 } from "@components/useLobbySocket";
 import { useCallback, useEffect, useRef, useState } from "react";
 import GameControls from "./GameControls";
 import useCountdown from "../useCountdown";
+import DrinkQueue from "./DrinkQueue";
 import TapOutControl from "./TapOutControl";
 
 // Mock data for fallback
@@ -181,7 +183,12 @@ const PlayerView = ({ lobbyId }) => {
             </p>
             {player.drinkNow > 0 && (
               <p className="text-sm font-semibold text-orange-600 mt-1">
-                Drink {player.drinkNow} {player.drinkNow === 1 ? 'sip' : 'sips'}
+                {gameState.unit?.alcohol ? 'Drink' : 'Do'} {formatUnits(player.drinkNow, gameState.unit)}
+              </p>
+            )}
+            {player.lastChallenge && (
+              <p className="text-sm font-semibold text-purple-700 mt-1">
+                Challenge: {player.lastChallenge}
               </p>
             )}
           </div>
@@ -192,6 +199,19 @@ const PlayerView = ({ lobbyId }) => {
 
   useEffect(() => () => stopMockUpdates(), []);
 
+  // Players arriving through a join link or PIN are moved to the lobby's
+  // word code so reloads don't reuse the spent link.
+  const moveToCanonicalCode = (data, name) => {
+    const code = data?.session?.code;
+    if (!code || code === lobbyId) return false;
+    localStorage.setItem(`playerNickname:${code}`, name);
+    if (data?.playerId) {
+      localStorage.setItem(`playerId:${code}`, data.playerId);
+    }
+    window.location.replace(`/play/${code}`);
+    return true;
+  };
+
   const handleAutoJoin = async (savedNickname) => {
     setLoading(true);
     try {
@@ -207,6 +227,7 @@ const PlayerView = ({ lobbyId }) => {
         localStorage.setItem(playerIdStorageKey, data.playerId);
         setPlayerId(data.playerId);
       }
+      if (moveToCanonicalCode(data, savedNickname)) return;
       setHasJoined(true);
     } catch {
       // allow manual join
@@ -241,6 +262,7 @@ const PlayerView = ({ lobbyId }) => {
       }
 
       localStorage.setItem(nicknameStorageKey, nickname.trim());
+      if (moveToCanonicalCode(data, nickname.trim())) return;
       setHasJoined(true);
     } catch {
       setUsingMock(true);
@@ -289,6 +311,16 @@ const PlayerView = ({ lobbyId }) => {
     );
   }
 
+  if (gameState?.lobbyEnded) {
+    return (
+      <div className="min-h-screen flex items-center justify-center p-4 bg-gray-50">
+        <p className="text-center text-gray-600">
+          This lobby ended. Ask the host for a new code.
+        </p>
+      </div>
+    );
+  }
+
   return (
     <div className="container mx-auto px-4 py-6 bg-gray-50 min-h-screen">
       <div className="mb-3 flex items-center gap-2">
@@ -353,6 +385,15 @@ const PlayerView = ({ lobbyId }) => {
         me={me}
         usingMock={usingMock}
       />
+
+      <DrinkQueue
+        lobbyId={lobbyId}
+        playerId={playerId}
+        me={me}
+        unit={gameState?.unit}
+        nameById={gameState?.nameById || {}}
+        usingMock={usingMock}
+      />
       {gameState?.deadline &&
         gameState?.phase !== "waiting" &&
         gameState?.phase !== "result" && (
@@ -21,9 +21,11 @@ const TapOutControl = ({
   if (!isGuessPhase || !me || me.isSpectator) return null;
 
   const canTap = !me.pendingTapOut;
+  const canCancel = me.pendingTapOut && Boolean(gameState?.tapOut?.allowCancel);
+  const penalty = gameState?.tapOut?.penalty ?? 0;
 
   const onTapOut = async () => {
-    if (!canTap || submitting) return;
+    if ((!canTap && !canCancel) || submitting) return;
     setSubmitting(true);
     setError("");
 
@@ -34,7 +36,8 @@ const TapOutControl = ({
 
     try {
       const body = playerId ? { playerId } : { nickname };
-      const res = await fetch(`/api/lobbies/${lobbyId}/tap`, {
+      const action = canCancel ? "tap/cancel" : "tap";
+      const res = await fetch(`/api/lobbies/${lobbyId}/${action}`, {
         method: "POST",
         headers: { "Content-Type": "application/json" },
         body: JSON.stringify(body),
@@ -51,12 +54,14 @@ const TapOutControl = ({
     <div className="mt-3">
       <button
         onClick={onTapOut}
-        disabled={!canTap || submitting}
+        disabled={(!canTap && !canCancel) || submitting}
         className="w-full py-2 rounded-lg font-medium bg-gray-800 text-white disabled:opacity-50"
       >
-        {me.pendingTapOut
-          ? "Tap out requested (next round)"
-          : "Tap out after this round"}
+        {canCancel
+          ? "Cancel tap out"
+          : me.pendingTapOut
+            ? "Tap out requested (next round)"
+            : `Tap out after this round${penalty > 0 ? ` (costs ${penalty})` : ""}`}
       </button>
 
       {me.pendingTapOut && (
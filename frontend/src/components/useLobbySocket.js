@@ -1,5 +1,11 @@
 import { useEffect, useRef, useState } from "react";
 
+const DEFAULT_UNIT = { kind: "sips", singular: "sip", plural: "sips", alcohol: true };
+
+// formatUnits renders an amount in the lobby's penalty unit ("3 sips").
+export const formatUnits = (amount, unit = DEFAULT_UNIT) =>
+  `${amount} ${amount === 1 ? unit.singular : unit.plural}`;
+
 export const mapSessionToViewState = (session) => {
   const game = session?.game;
   const shared = game?.shared || [];
@@ -32,45 +38,12 @@ export const mapSessionToViewState = (session) => {
     return v === "inside" ? "between" : v;
   };
 
-  const isCorrectGuessForRound = (roundIndex, guess) => {
-    const g = normalizeGuess(guess);
-    const c0 = shared[0];
-    const c1 = shared[1];
-    const c2 = shared[2];
-    const c3 = shared[3];
-
-    if (!c0 || !c0.rank) return null;
-
-    switch (roundIndex) {
-      case 0: {
-        const isRed = ["hearts", "diamonds"].includes(
-          String(c0.suit).toLowerCase(),
-        );
-        return (g === "red" && isRed) || (g === "black" && !isRed);
-      }
-      case 1: {
-        if (!c1 || !c1.rank) return null;
-        return (
-          (g === "higher" && c1.rank > c0.rank) ||
-          (g === "lower" && c1.rank < c0.rank)
-        );
-      }
-      case 2: {
-        if (!c1 || !c1.rank || !c2 || !c2.rank) return null;
-        const low = Math.min(c0.rank, c1.rank);
-        const high = Math.max(c0.rank, c1.rank);
-        const between = c2.rank > low && c2.rank < high;
-        const outside = c2.rank < low || c2.rank > high;
-        return (g === "between" && between) || (g === "outside" && outside);
-      }
-      case 3: {
-        if (!c3 || !c3.rank) return null;
-        return String(c3.suit).toLowerCase() === g;
-      }
-      default:
-        return null;
-    }
-  };
+  // Settled outcomes come from the server; the latest result per player wins.
+  const lastResultByPlayer = {};
+  for (const r of game?.results || []) {
+    const prev = lastResultByPlayer[r.playerId];
+    if (!prev || r.round >= prev.round) lastResultByPlayer[r.playerId] = r;
+  }
 
   let currentCard = null;
   let previousCard = null;
@@ -93,6 +66,11 @@ export const mapSessionToViewState = (session) => {
   const drinkNowByPlayer = game?.drinkNowByPlayer || {};
   const giveOutRemainingByPlayer = game?.giveOutRemainingByPlayer || {};
   const pendingTapOutByPlayer = game?.pendingTapOutByPlayer || {};
+  const ledger = session?.ledger || [];
+  const teamByPlayer = {};
+  for (const t of session?.teams || []) {
+    for (const id of t.members || []) teamByPlayer[id] = t;
+  }
 
   const players = (session?.players || [])
     .filter((p) => p.id !== session?.hostId)
@@ -104,31 +82,37 @@ export const mapSessionToViewState = (session) => {
       const hasGuessedThisRound =
         guesses.length > round && guesses[round] !== "";
 
-      // Last completed round from UI perspective
-      const completedRound = Math.min(round - 1, 3);
-      const lastGuessRound = Math.min(guesses.length - 1, completedRound);
-
-      const lastGuess = lastGuessRound >= 0 ? guesses[lastGuessRound] : null;
-      const lastGuessCorrect =
-        lastGuessRound >= 0
-          ? isCorrectGuessForRound(lastGuessRound, lastGuess)
-          : null;
+      const myPendingDrinks = ledger.filter((e) => e.toId === p.id && !e.acked);
+      const lastResult = lastResultByPlayer[p.id] ?? null;
+      const lastGuessRound = lastResult ? lastResult.round : -1;
+      const lastGuess = lastResult ? normalizeGuess(lastResult.guess) : null;
+      const lastGuessCorrect = lastResult ? Boolean(lastResult.correct) : null;
+      const lastChallenge = lastResult?.challenge || null;
 
       return {
         id: p.id,
         nickname: p.name,
+        isBot: Boolean(p.bot),
         ready: hasGuessedThisRound,
         score: p.score || 0,
         lifetimeDrank: p.lifetimeDrank ?? p.score ?? 0,
         givenOut: p.givenOut ?? 0,
         drinkNow: drinkNowByPlayer[p.id] ?? 0,
         giveOutRemaining: giveOutRemainingByPlayer[p.id] ?? 0,
+        riding: game?.ridingByPlayer?.[p.id] ?? 0,
         pendingTapOut: Boolean(pendingTapOutByPlayer[p.id]),
+        status: game?.statusByPlayer?.[p.id] ?? "active",
+        pendingDrinks: myPendingDrinks,
+        owed: myPendingDrinks.reduce((sum, d) => sum + d.amount, 0),
         guesses,
         lastGuess,
         lastGuessRound,
         lastGuessCorrect,
+        lastChallenge,
         isSpectator,
+        teamId: teamByPlayer[p.id]?.id ?? null,
+        teamName: teamByPlayer[p.id]?.name ?? null,
+        isCaptain: teamByPlayer[p.id]?.captainId === p.id,
       };
     });
 
@@ -142,12 +126,30 @@ export const mapSessionToViewState = (session) => {
     distributionDeadline,
     activePlayersCount: activePlayers.length,
     noActivePlayersLeft,
+    results: game?.results || [],
+    teams: session?.teams || [],
+    pin: session?.pin || "",
+    paused: Boolean(game?.paused),
+    odds: game?.odds || null,
+    customRounds: session?.settings?.rounds?.length
+      ? session.settings.rounds
+      : null,
+    unit: session?.unit?.plural ? session.unit : DEFAULT_UNIT,
+    requireDrinkAck: Boolean(session?.settings?.requireDrinkAck),
+    tapOut: session?.settings?.tapOut || {},
+    nameById: Object.fromEntries(
+      (session?.players || []).map((p) => [p.id, p.name]),
+    ),
+    undo: session?.undo
+      ? { action: session.undo.action, at: session.undo.at }
+      : null,
     lobbyStatus: session?.status ?? "active",
+    lobbyEnded: Boolean(session?.ended),
     shuttingDownAt: session?.shuttingDownAt ?? null,
   };
 };
 
-export default function useLobbySocket({ lobbyId, onSession }) {
+export default function useLobbySocket({ lobbyId, onSession, onEvent }) {
   const [connected, setConnected] = useState(false);
   const wsRef = useRef(null);
   const reconnectTimerRef = useRef(null);
@@ -170,6 +172,8 @@ export default function useLobbySocket({ lobbyId, onSession }) {
           const data = JSON.parse(event.data);
           if (data.type === "session" && data.session) {
             onSession(data.session);
+          } else if (data.type && data.data !== undefined) {
+            onEvent?.(data.type, data.data, data.unit);
           } else if (data.code) {
             onSession(data);
           }
@@ -195,7 +199,7 @@ export default function useLobbySocket({ lobbyId, onSession }) {
         wsRef.current.close();
       }
     };
-  }, [lobbyId, onSession]);
+  }, [lobbyId, onSession, onEvent]);
 
   return { connected };
 }